@@ -4,19 +4,44 @@ import (
     "log"
     "os"
     "strconv"
+    "strings"
     
     "github.com/joho/godotenv"
 )
 
 type Config struct {
-    DatabaseURL    string
-    RedisAddr      string
-    RedisPassword  string
-    RedisDB        int
-    Port           string
-    BaseURL        string
-    JWTSecret      string
-    JWTExpiryHours int
+    DatabaseDriver          string
+    DatabaseURL             string
+    SQLitePath              string
+    CacheDriver             string
+    CacheMaxItems           int
+    RedisAddr               string
+    RedisPassword           string
+    RedisDB                 int
+    Port                    string
+    BaseURL                 string
+    JWTSecret               string
+    JWTExpiryHours          int
+    ClickFlushSeconds       int
+    ClickEventBuffer        int
+    ClickEventBatch         int
+    IPHashSecret            string
+    GeoIPPath               string
+    GeoIPCity               bool
+    DeleteRetentionDays     int
+    PausedRedirectURL       string
+    PausedMessage           string
+    ExpiredURLAction        string
+    ExpiredGraceHours       int
+    ReaperIntervalMinutes   int
+    DefaultRedirectStatus   int
+    PermanentRedirectMaxAge int
+    TrustedProxies          []string
+    ClientIPHeaders         []string
+    IOSAppIDs               []string
+    IOSAppPaths             []string
+    AndroidAppPackage       string
+    AndroidCertFingerprints []string
 }
 
 var AppConfig *Config
@@ -31,15 +56,60 @@ func LoadConfig() {
     redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
     jwtExpiry, _ := strconv.Atoi(getEnv("JWT_EXPIRY_HOURS", "24"))
     
+    jwtSecret := getEnv("JWT_SECRET", "default-secret-change-me")
+    
+    databaseDriver := getEnv("DATABASE_DRIVER", "postgres")
+    
+    // Links without their own choice use this; the 302 default keeps
+    // browsers coming back so repeat visits are counted
+    redirectStatus := getEnvPositiveInt("DEFAULT_REDIRECT_STATUS", 302)
+    switch redirectStatus {
+    case 301, 302, 307, 308:
+    default:
+        log.Printf("Invalid DEFAULT_REDIRECT_STATUS %d, using 302", redirectStatus)
+        redirectStatus = 302
+    }
+    
+    // Only the Postgres deployment expects a Redis server by default;
+    // the embedded backends keep everything in process
+    defaultCache := "memory"
+    if databaseDriver == "postgres" {
+        defaultCache = "redis"
+    }
+    
     AppConfig = &Config{
-        DatabaseURL:    getEnv("DATABASE_URL", ""),
-        RedisAddr:      getEnv("REDIS_ADDR", "localhost:6379"),
-        RedisPassword:  getEnv("REDIS_PASSWORD", ""),
-        RedisDB:        redisDB,
-        Port:           getEnv("PORT", "8080"),
-        BaseURL:        getEnv("BASE_URL", "http://localhost:8080"),
-        JWTSecret:      getEnv("JWT_SECRET", "default-secret-change-me"),
-        JWTExpiryHours: jwtExpiry,
+        DatabaseDriver:          databaseDriver,
+        DatabaseURL:             getEnv("DATABASE_URL", ""),
+        SQLitePath:              getEnv("SQLITE_PATH", "shortener.db"),
+        CacheDriver:             getEnv("CACHE_DRIVER", defaultCache),
+        CacheMaxItems:           getEnvPositiveInt("CACHE_MAX_ITEMS", 10000),
+        RedisAddr:               getEnv("REDIS_ADDR", "localhost:6379"),
+        RedisPassword:           getEnv("REDIS_PASSWORD", ""),
+        RedisDB:                 redisDB,
+        Port:                    getEnv("PORT", "8080"),
+        BaseURL:                 getEnv("BASE_URL", "http://localhost:8080"),
+        JWTSecret:               jwtSecret,
+        JWTExpiryHours:          jwtExpiry,
+        ClickFlushSeconds:       getEnvPositiveInt("CLICK_FLUSH_SECONDS", 5),
+        ClickEventBuffer:        getEnvPositiveInt("CLICK_EVENT_BUFFER", 10000),
+        ClickEventBatch:         getEnvPositiveInt("CLICK_EVENT_BATCH", 500),
+        IPHashSecret:            getEnv("IP_HASH_SECRET", jwtSecret),
+        GeoIPPath:               getEnv("GEOIP_DB_PATH", ""),
+        GeoIPCity:               getEnv("GEOIP_CITY", "false") == "true",
+        DeleteRetentionDays:     getEnvPositiveInt("DELETE_RETENTION_DAYS", 30),
+        PausedRedirectURL:       getEnv("PAUSED_REDIRECT_URL", ""),
+        PausedMessage:           getEnv("PAUSED_MESSAGE", "This link is currently paused"),
+        ExpiredURLAction:        getEnv("EXPIRED_URL_ACTION", "archive"),
+        ExpiredGraceHours:       getEnvPositiveInt("EXPIRED_GRACE_HOURS", 7*24),
+        ReaperIntervalMinutes:   getEnvPositiveInt("REAPER_INTERVAL_MINUTES", 60),
+        DefaultRedirectStatus:   redirectStatus,
+        PermanentRedirectMaxAge: getEnvPositiveInt("PERMANENT_REDIRECT_MAX_AGE", 24*60*60),
+        TrustedProxies:          getEnvList("TRUSTED_PROXIES", ""),
+        ClientIPHeaders:         getEnvList("CLIENT_IP_HEADERS", "X-Forwarded-For,X-Real-IP"),
+        IOSAppIDs:               getEnvList("IOS_APP_IDS", ""),
+        IOSAppPaths:             getEnvList("IOS_APP_PATHS", ""),
+        AndroidAppPackage:       getEnv("ANDROID_APP_PACKAGE", ""),
+        AndroidCertFingerprints: getEnvList("ANDROID_CERT_FINGERPRINTS", ""),
     }
     
     log.Println("Configuration loaded successfully")
@@ -52,3 +122,24 @@ func getEnv(key, defaultValue string) string {
     }
     return defaultValue
 }
+
+// getEnvList reads a comma separated list, dropping empty entries
+func getEnvList(key, defaultValue string) []string {
+    var values []string
+    for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+        if value = strings.TrimSpace(value); value != "" {
+            values = append(values, value)
+        }
+    }
+    return values
+}
+
+// getEnvPositiveInt reads a positive integer, falling back to defaultValue
+// when the variable is unset or not a positive number
+func getEnvPositiveInt(key string, defaultValue int) int {
+    value, err := strconv.Atoi(os.Getenv(key))
+    if err != nil || value <= 0 {
+        return defaultValue
+    }
+    return value
+}
@@ -0,0 +1,303 @@
+package database
+
+import (
+    "context"
+    "database/sql"
+    "embed"
+    "fmt"
+    "io/fs"
+    "log"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationLockID is the advisory lock key held while migrating, so that
+// replicas starting at the same time don't apply migrations concurrently
+const migrationLockID = 72364581
+
+// Migration is one versioned schema change
+type Migration struct {
+    Version int
+    Name    string
+    Up      string
+    Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+    Migration
+    AppliedAt *time.Time
+}
+
+// Migrator applies embedded SQL migrations and records them in
+// the schema_migrations table
+type Migrator struct {
+    db         *sql.DB
+    dialect    string
+    migrations []Migration
+}
+
+// NewMigrator loads the migrations embedded for the given dialect
+// ("postgres" or "sqlite")
+func NewMigrator(db *sql.DB, dialect string) (*Migrator, error) {
+    migrations, err := loadMigrations(path.Join("migrations", dialect))
+    if err != nil {
+        return nil, err
+    }
+
+    return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// loadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs from dir
+func loadMigrations(dir string) ([]Migration, error) {
+    entries, err := fs.ReadDir(migrationFiles, dir)
+    if err != nil {
+        return nil, err
+    }
+
+    byVersion := make(map[int]*Migration)
+    for _, entry := range entries {
+        name := entry.Name()
+
+        var direction string
+        switch {
+        case strings.HasSuffix(name, ".up.sql"):
+            direction = "up"
+        case strings.HasSuffix(name, ".down.sql"):
+            direction = "down"
+        default:
+            continue
+        }
+
+        base := strings.TrimSuffix(name, "."+direction+".sql")
+        versionPart, label, ok := strings.Cut(base, "_")
+        if !ok {
+            return nil, fmt.Errorf("invalid migration file name %q", name)
+        }
+
+        version, err := strconv.Atoi(versionPart)
+        if err != nil {
+            return nil, fmt.Errorf("invalid migration version in %q", name)
+        }
+
+        body, err := fs.ReadFile(migrationFiles, path.Join(dir, name))
+        if err != nil {
+            return nil, err
+        }
+
+        m, exists := byVersion[version]
+        if !exists {
+            m = &Migration{Version: version, Name: label}
+            byVersion[version] = m
+        }
+
+        if direction == "up" {
+            m.Up = string(body)
+        } else {
+            m.Down = string(body)
+        }
+    }
+
+    migrations := make([]Migration, 0, len(byVersion))
+    for _, m := range byVersion {
+        if m.Up == "" {
+            return nil, fmt.Errorf("migration %04d_%s has no up script", m.Version, m.Name)
+        }
+        migrations = append(migrations, *m)
+    }
+
+    sort.Slice(migrations, func(i, j int) bool {
+        return migrations[i].Version < migrations[j].Version
+    })
+
+    return migrations, nil
+}
+
+// Status lists every known migration with its applied time, if any
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+    ctx := context.Background()
+
+    conn, err := m.db.Conn(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer conn.Close()
+
+    if err := ensureMigrationsTable(ctx, conn); err != nil {
+        return nil, err
+    }
+
+    applied, err := appliedMigrations(ctx, conn)
+    if err != nil {
+        return nil, err
+    }
+
+    statuses := make([]MigrationStatus, 0, len(m.migrations))
+    for _, migration := range m.migrations {
+        status := MigrationStatus{Migration: migration}
+        if appliedAt, ok := applied[migration.Version]; ok {
+            at := appliedAt
+            status.AppliedAt = &at
+        }
+        statuses = append(statuses, status)
+    }
+
+    return statuses, nil
+}
+
+// Up applies all pending migrations in order and returns how many ran
+func (m *Migrator) Up() (int, error) {
+    count := 0
+
+    err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+        applied, err := appliedMigrations(ctx, conn)
+        if err != nil {
+            return err
+        }
+
+        for _, migration := range m.migrations {
+            if _, ok := applied[migration.Version]; ok {
+                continue
+            }
+
+            if err := applyMigration(ctx, conn, migration.Up, func(tx *sql.Tx) error {
+                _, err := tx.ExecContext(ctx,
+                    `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+                    migration.Version, migration.Name, time.Now().UTC(),
+                )
+                return err
+            }); err != nil {
+                return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+            }
+
+            log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+            count++
+        }
+
+        return nil
+    })
+
+    return count, err
+}
+
+// Down rolls back the n most recently applied migrations
+func (m *Migrator) Down(n int) (int, error) {
+    count := 0
+
+    err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+        applied, err := appliedMigrations(ctx, conn)
+        if err != nil {
+            return err
+        }
+
+        for i := len(m.migrations) - 1; i >= 0 && count < n; i-- {
+            migration := m.migrations[i]
+            if _, ok := applied[migration.Version]; !ok {
+                continue
+            }
+
+            if migration.Down == "" {
+                return fmt.Errorf("migration %04d_%s has no down script", migration.Version, migration.Name)
+            }
+
+            if err := applyMigration(ctx, conn, migration.Down, func(tx *sql.Tx) error {
+                _, err := tx.ExecContext(ctx,
+                    `DELETE FROM schema_migrations WHERE version = $1`,
+                    migration.Version,
+                )
+                return err
+            }); err != nil {
+                return fmt.Errorf("rollback %04d_%s: %w", migration.Version, migration.Name, err)
+            }
+
+            log.Printf("Rolled back migration %04d_%s", migration.Version, migration.Name)
+            count++
+        }
+
+        return nil
+    })
+
+    return count, err
+}
+
+// withLock runs fn on a single connection holding the migration lock
+func (m *Migrator) withLock(fn func(ctx context.Context, conn *sql.Conn) error) error {
+    ctx := context.Background()
+
+    conn, err := m.db.Conn(ctx)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+
+    // SQLite is a single local file with its own write lock, so only
+    // Postgres needs a cross-replica lock
+    if m.dialect == "postgres" {
+        if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+            return fmt.Errorf("acquire migration lock: %w", err)
+        }
+        defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID)
+    }
+
+    if err := ensureMigrationsTable(ctx, conn); err != nil {
+        return err
+    }
+
+    return fn(ctx, conn)
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+    _, err := conn.ExecContext(ctx, `
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        version BIGINT PRIMARY KEY,
+        name TEXT NOT NULL,
+        applied_at TIMESTAMP NOT NULL
+    )`)
+    return err
+}
+
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+    rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    applied := make(map[int]time.Time)
+    for rows.Next() {
+        var version int
+        var appliedAt time.Time
+        if err := rows.Scan(&version, &appliedAt); err != nil {
+            return nil, err
+        }
+        applied[version] = appliedAt
+    }
+
+    return applied, rows.Err()
+}
+
+// applyMigration runs script and the bookkeeping in record in one transaction
+func applyMigration(ctx context.Context, conn *sql.Conn, script string, record func(tx *sql.Tx) error) error {
+    tx, err := conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+
+    if _, err := tx.ExecContext(ctx, script); err != nil {
+        tx.Rollback()
+        return err
+    }
+
+    if err := record(tx); err != nil {
+        tx.Rollback()
+        return err
+    }
+
+    return tx.Commit()
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS clicks (
+    id BIGSERIAL PRIMARY KEY,
+    short_code VARCHAR(20) NOT NULL,
+    clicked_at TIMESTAMP NOT NULL,
+    referrer TEXT,
+    user_agent TEXT,
+    ip_hash VARCHAR(64),
+    accept_language VARCHAR(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_clicks_code_time ON clicks(short_code, clicked_at);
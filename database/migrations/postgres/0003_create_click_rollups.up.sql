@@ -0,0 +1,14 @@
+-- Hourly click totals, kept up to date as click events are written, so
+-- long time-series ranges don't have to scan the raw clicks table
+CREATE TABLE IF NOT EXISTS click_rollups (
+    short_code VARCHAR(20) NOT NULL,
+    bucket_start TIMESTAMP NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (short_code, bucket_start)
+);
+
+INSERT INTO click_rollups (short_code, bucket_start, clicks)
+SELECT short_code, date_trunc('hour', clicked_at), COUNT(*)
+FROM clicks
+GROUP BY 1, 2
+ON CONFLICT (short_code, bucket_start) DO NOTHING;
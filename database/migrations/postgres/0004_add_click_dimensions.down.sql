@@ -0,0 +1,4 @@
+ALTER TABLE clicks DROP COLUMN device;
+ALTER TABLE clicks DROP COLUMN os;
+ALTER TABLE clicks DROP COLUMN browser;
+ALTER TABLE clicks DROP COLUMN referrer_domain;
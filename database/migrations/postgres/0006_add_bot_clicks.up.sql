@@ -0,0 +1,5 @@
+-- Bot and crawler traffic is still redirected but counted apart from
+-- human clicks. Existing clicks can't be reclassified and stay human.
+ALTER TABLE urls ADD COLUMN bot_clicks BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE clicks ADD COLUMN is_bot BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE click_rollups ADD COLUMN bot_clicks BIGINT NOT NULL DEFAULT 0;
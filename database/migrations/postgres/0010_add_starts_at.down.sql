@@ -0,0 +1 @@
+ALTER TABLE urls DROP COLUMN starts_at;
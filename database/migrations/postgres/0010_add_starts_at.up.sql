@@ -0,0 +1,2 @@
+-- Links with a starts_at in the future exist but don't redirect yet
+ALTER TABLE urls ADD COLUMN starts_at TIMESTAMP;
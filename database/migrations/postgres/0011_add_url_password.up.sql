@@ -0,0 +1,2 @@
+-- bcrypt hash of the optional password visitors must enter before redirecting
+ALTER TABLE urls ADD COLUMN password_hash VARCHAR(255);
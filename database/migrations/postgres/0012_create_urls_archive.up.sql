@@ -0,0 +1,15 @@
+-- Expired links moved out of urls by the reaper. Archived codes stay
+-- reserved so they are never reissued to a different destination.
+CREATE TABLE IF NOT EXISTS urls_archive (
+    id INTEGER NOT NULL,
+    short_code VARCHAR(20) PRIMARY KEY,
+    original_url TEXT NOT NULL,
+    user_id INTEGER,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    bot_clicks BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    archived_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_urls_expires_at ON urls(expires_at);
@@ -0,0 +1,3 @@
+-- Redirect status chosen by the owner (301, 302, 307 or 308); NULL uses
+-- the server default
+ALTER TABLE urls ADD COLUMN redirect_status INTEGER;
@@ -0,0 +1,2 @@
+ALTER TABLE clicks DROP COLUMN variant;
+ALTER TABLE urls DROP COLUMN variants;
@@ -0,0 +1,6 @@
+-- Weighted A/B destinations as a JSON array; NULL when the link has a
+-- single destination
+ALTER TABLE urls ADD COLUMN variants JSONB;
+
+-- Name of the variant a click was sent to
+ALTER TABLE clicks ADD COLUMN variant VARCHAR(32);
@@ -0,0 +1,5 @@
+-- redirect, or deeplink for links served as an app bounce page
+ALTER TABLE urls ADD COLUMN link_type VARCHAR(20) NOT NULL DEFAULT 'redirect';
+
+-- Per-platform app and store targets of a deeplink link as a JSON object
+ALTER TABLE urls ADD COLUMN deep_link JSONB;
@@ -0,0 +1,4 @@
+ALTER TABLE clicks ADD COLUMN referrer_domain VARCHAR(255);
+ALTER TABLE clicks ADD COLUMN browser VARCHAR(50);
+ALTER TABLE clicks ADD COLUMN os VARCHAR(50);
+ALTER TABLE clicks ADD COLUMN device VARCHAR(20);
@@ -0,0 +1,3 @@
+ALTER TABLE clicks ADD COLUMN country VARCHAR(2);
+ALTER TABLE clicks ADD COLUMN region VARCHAR(10);
+ALTER TABLE clicks ADD COLUMN city VARCHAR(100);
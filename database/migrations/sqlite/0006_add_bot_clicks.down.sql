@@ -0,0 +1,3 @@
+ALTER TABLE click_rollups DROP COLUMN bot_clicks;
+ALTER TABLE clicks DROP COLUMN is_bot;
+ALTER TABLE urls DROP COLUMN bot_clicks;
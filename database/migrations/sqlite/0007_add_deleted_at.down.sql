@@ -0,0 +1 @@
+ALTER TABLE urls DROP COLUMN deleted_at;
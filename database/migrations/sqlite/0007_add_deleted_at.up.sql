@@ -0,0 +1,3 @@
+-- Deleted links keep their row as a tombstone so the short code is never
+-- handed out again
+ALTER TABLE urls ADD COLUMN deleted_at TIMESTAMP;
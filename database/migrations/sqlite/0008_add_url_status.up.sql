@@ -0,0 +1,2 @@
+-- active or paused; paused links keep their code but don't redirect
+ALTER TABLE urls ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active';
@@ -0,0 +1,5 @@
+-- redirect_count is only maintained for links with a max_clicks budget and
+-- is incremented by a conditional UPDATE, so the limit holds across
+-- concurrent requests and instances
+ALTER TABLE urls ADD COLUMN max_clicks BIGINT;
+ALTER TABLE urls ADD COLUMN redirect_count BIGINT NOT NULL DEFAULT 0;
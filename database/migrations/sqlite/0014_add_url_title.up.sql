@@ -0,0 +1,2 @@
+-- Owner supplied title shown on the link preview page
+ALTER TABLE urls ADD COLUMN title VARCHAR(255);
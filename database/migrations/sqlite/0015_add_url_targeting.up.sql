@@ -0,0 +1,3 @@
+-- Ordered device/OS targeting rules as a JSON array; NULL when every
+-- visitor goes to original_url
+ALTER TABLE urls ADD COLUMN targeting TEXT;
@@ -0,0 +1,3 @@
+-- Country code to destination map as a JSON object; NULL when the link
+-- is not geo targeted
+ALTER TABLE urls ADD COLUMN geo_targeting TEXT;
@@ -0,0 +1,2 @@
+ALTER TABLE urls DROP COLUMN deep_link;
+ALTER TABLE urls DROP COLUMN link_type;
@@ -0,0 +1,6 @@
+-- Codes of links purged by the reaper. Only the code is kept so it is
+-- never reissued to a different destination.
+CREATE TABLE IF NOT EXISTS reserved_codes (
+    short_code VARCHAR(20) PRIMARY KEY,
+    reserved_at TIMESTAMP NOT NULL
+);
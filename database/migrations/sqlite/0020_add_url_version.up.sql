@@ -0,0 +1,3 @@
+-- Bumped on every edit so concurrent edits of a link can't silently
+-- overwrite each other
+ALTER TABLE urls ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
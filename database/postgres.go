@@ -10,7 +10,8 @@ import (
 var DB *sql.DB
 
 
-func InitPostgres(connectionString string) error {
+// ConnectPostgres opens the connection pool without touching the schema
+func ConnectPostgres(connectionString string) error {
     var err error
     
     
@@ -29,51 +30,26 @@ func InitPostgres(connectionString string) error {
     DB.SetMaxIdleConns(5)
     
     log.Println("PostgreSQL connected successfully")
-    
-  
-    if err := createTables(); err != nil {
-        return err
-    }
-    
     return nil
 }
 
-func createTables() error {
- 
-    userTable := `
-    CREATE TABLE IF NOT EXISTS users (
-        id SERIAL PRIMARY KEY,
-        username VARCHAR(50) UNIQUE NOT NULL,
-        email VARCHAR(100) UNIQUE NOT NULL,
-        password_hash VARCHAR(255) NOT NULL,
-        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-    );`
-    
-    // URLs table
-    urlTable := `
-    CREATE TABLE IF NOT EXISTS urls (
-        id SERIAL PRIMARY KEY,
-        short_code VARCHAR(10) UNIQUE NOT NULL,
-        original_url TEXT NOT NULL,
-        user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
-        clicks BIGINT DEFAULT 0,
-        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-        expires_at TIMESTAMP
-    );
-    
-    CREATE INDEX IF NOT EXISTS idx_short_code ON urls(short_code);
-    CREATE INDEX IF NOT EXISTS idx_user_id ON urls(user_id);`
+// InitPostgres connects and applies any pending migrations
+func InitPostgres(connectionString string) error {
+    if err := ConnectPostgres(connectionString); err != nil {
+        return err
+    }
     
- 
-    if _, err := DB.Exec(userTable); err != nil {
+    migrator, err := NewMigrator(DB, "postgres")
+    if err != nil {
         return err
     }
     
-    if _, err := DB.Exec(urlTable); err != nil {
+    applied, err := migrator.Up()
+    if err != nil {
         return err
     }
     
-    log.Println("Database tables created/verified")
+    log.Printf("Database schema up to date (%d migrations applied)", applied)
     return nil
 }
 
@@ -0,0 +1,51 @@
+package database
+
+import (
+    "database/sql"
+    "log"
+    
+    _ "modernc.org/sqlite"
+)
+
+// sqlitePragmas are applied to every connection: WAL lets readers run
+// alongside the single writer, busy_timeout waits out short write locks,
+// and timestamps are stored in a lexically sortable format
+const sqlitePragmas = "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_time_format=sqlite"
+
+
+// ConnectSQLite opens the database file at path, creating it if needed
+func ConnectSQLite(path string) error {
+    var err error
+    
+    DB, err = sql.Open("sqlite", "file:"+path+sqlitePragmas)
+    if err != nil {
+        return err
+    }
+    
+    if err = DB.Ping(); err != nil {
+        return err
+    }
+    
+    log.Printf("SQLite database opened at %s", path)
+    return nil
+}
+
+// InitSQLite opens the database file and applies any pending migrations
+func InitSQLite(path string) error {
+    if err := ConnectSQLite(path); err != nil {
+        return err
+    }
+    
+    migrator, err := NewMigrator(DB, "sqlite")
+    if err != nil {
+        return err
+    }
+    
+    applied, err := migrator.Up()
+    if err != nil {
+        return err
+    }
+    
+    log.Printf("Database schema up to date (%d migrations applied)", applied)
+    return nil
+}
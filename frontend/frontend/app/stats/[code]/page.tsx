@@ -4,11 +4,20 @@ import { useEffect, useState } from 'react';
 import { useParams } from 'next/navigation';
 import api from '@/app/lib/api'; 
 
+type Granularity = 'hour' | 'day' | 'week';
+
+interface Bucket {
+  start: string;
+  clicks: number;
+}
+
 export default function StatsPage() {
   const { code } = useParams();
   const [stats, setStats] = useState<any>(null);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState('');
+  const [granularity, setGranularity] = useState<Granularity>('day');
+  const [buckets, setBuckets] = useState<Bucket[]>([]);
 
   useEffect(() => {
     if (code) {
@@ -16,6 +25,33 @@ export default function StatsPage() {
     }
   }, [code]);
 
+  useEffect(() => {
+    if (code) {
+      fetchTimeSeries();
+    }
+  }, [code, granularity]);
+
+  const fetchTimeSeries = async () => {
+    try {
+      const tz = Intl.DateTimeFormat().resolvedOptions().timeZone;
+      const response = await api.get(`/url/${code}/stats/timeseries`, {
+        params: { granularity, tz },
+      });
+      setBuckets(response.data.buckets || []);
+    } catch (err) {
+      setBuckets([]);
+    }
+  };
+
+  const formatBucket = (start: string) => {
+    const date = new Date(start);
+    return granularity === 'hour'
+      ? date.toLocaleString(undefined, { month: 'short', day: 'numeric', hour: '2-digit' })
+      : date.toLocaleDateString(undefined, { month: 'short', day: 'numeric' });
+  };
+
+  const maxClicks = Math.max(1, ...buckets.map((b) => b.clicks));
+
   const fetchStats = async () => {
     try {
       const response = await api.get(`/url/${code}/stats`);
@@ -61,6 +97,49 @@ export default function StatsPage() {
                     {stats.short_url}
                 </a>
             </div>
+
+            <div className="col-span-1 md:col-span-2 bg-gray-50 dark:bg-gray-700 p-4 rounded-lg">
+                <div className="flex items-center justify-between mb-4">
+                    <p className="text-sm text-gray-500 dark:text-gray-400">Clicks over time</p>
+                    <div className="flex gap-1">
+                        {(['hour', 'day', 'week'] as Granularity[]).map((g) => (
+                            <button
+                                key={g}
+                                onClick={() => setGranularity(g)}
+                                className={`px-2 py-1 text-xs rounded ${
+                                    granularity === g
+                                        ? 'bg-indigo-600 text-white'
+                                        : 'bg-white dark:bg-gray-800 text-gray-600 dark:text-gray-300'
+                                }`}
+                            >
+                                {g}
+                            </button>
+                        ))}
+                    </div>
+                </div>
+
+                {buckets.length === 0 ? (
+                    <p className="text-sm text-gray-500 dark:text-gray-400">No click data yet</p>
+                ) : (
+                    <div className="flex items-end gap-px h-40">
+                        {buckets.map((bucket) => (
+                            <div
+                                key={bucket.start}
+                                title={`${formatBucket(bucket.start)}: ${bucket.clicks} clicks`}
+                                className="flex-1 bg-indigo-500 dark:bg-indigo-400 rounded-t"
+                                style={{ height: `${(bucket.clicks / maxClicks) * 100}%`, minHeight: bucket.clicks ? 2 : 0 }}
+                            />
+                        ))}
+                    </div>
+                )}
+
+                {buckets.length > 0 && (
+                    <div className="flex justify-between mt-2 text-xs text-gray-500 dark:text-gray-400">
+                        <span>{formatBucket(buckets[0].start)}</span>
+                        <span>{formatBucket(buckets[buckets.length - 1].start)}</span>
+                    </div>
+                )}
+            </div>
         </div>
       </div>
     </div>
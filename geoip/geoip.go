@@ -0,0 +1,93 @@
+package geoip
+
+import (
+    "errors"
+    "io/fs"
+    "log"
+    "net/netip"
+    
+    "github.com/oschwald/maxminddb-golang/v2"
+)
+
+// Location is where an IP address is registered. Fields the database
+// doesn't cover are left empty.
+type Location struct {
+    Country string // ISO 3166-1 alpha-2
+    Region  string // ISO 3166-2 subdivision code
+    City    string
+}
+
+// Resolver looks up IPs in a local MaxMind-format (.mmdb) database.
+// A nil Resolver is valid and resolves nothing, which is how geo
+// attribution is disabled.
+type Resolver struct {
+    db       *maxminddb.Reader
+    withCity bool
+}
+
+type record struct {
+    Country struct {
+        ISOCode string `maxminddb:"iso_code"`
+    } `maxminddb:"country"`
+    Subdivisions []struct {
+        ISOCode string `maxminddb:"iso_code"`
+    } `maxminddb:"subdivisions"`
+    City struct {
+        Names map[string]string `maxminddb:"names"`
+    } `maxminddb:"city"`
+}
+
+// Open loads the database at path. withCity also resolves region and
+// city when the database has them. An empty path or a missing file
+// returns a nil Resolver and no error.
+func Open(path string, withCity bool) (*Resolver, error) {
+    if path == "" {
+        return nil, nil
+    }
+    
+    db, err := maxminddb.Open(path)
+    if errors.Is(err, fs.ErrNotExist) {
+        log.Printf("GeoIP database %s not found, geo attribution disabled", path)
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    
+    log.Printf("GeoIP database loaded (%s)", db.Metadata.DatabaseType)
+    return &Resolver{db: db, withCity: withCity}, nil
+}
+
+// Lookup resolves ip, returning an empty Location when it is unknown
+func (r *Resolver) Lookup(ip string) Location {
+    if r == nil {
+        return Location{}
+    }
+    
+    addr, err := netip.ParseAddr(ip)
+    if err != nil {
+        return Location{}
+    }
+    
+    var rec record
+    if err := r.db.Lookup(addr.Unmap()).Decode(&rec); err != nil {
+        return Location{}
+    }
+    
+    location := Location{Country: rec.Country.ISOCode}
+    if r.withCity {
+        if len(rec.Subdivisions) > 0 && rec.Subdivisions[0].ISOCode != "" && location.Country != "" {
+            location.Region = location.Country + "-" + rec.Subdivisions[0].ISOCode
+        }
+        location.City = rec.City.Names["en"]
+    }
+    
+    return location
+}
+
+func (r *Resolver) Close() error {
+    if r == nil {
+        return nil
+    }
+    return r.db.Close()
+}
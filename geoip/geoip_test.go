@@ -0,0 +1,38 @@
+package geoip
+
+import "testing"
+
+// testdata/country.mmdb maps 127.0.0.0/8 to DE and nothing else
+const testDatabase = "testdata/country.mmdb"
+
+func TestLookup(t *testing.T) {
+    resolver, err := Open(testDatabase, false)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    if got := resolver.Lookup("127.0.0.1").Country; got != "DE" {
+        t.Errorf("Lookup(127.0.0.1) country = %q, want DE", got)
+    }
+    if got := resolver.Lookup("::ffff:127.0.0.1").Country; got != "DE" {
+        t.Errorf("Lookup of an IPv4-mapped address = %q, want DE", got)
+    }
+    if got := resolver.Lookup("192.0.2.1"); got != (Location{}) {
+        t.Errorf("Lookup(192.0.2.1) = %+v, want empty", got)
+    }
+    if got := resolver.Lookup("not an ip"); got != (Location{}) {
+        t.Errorf("Lookup(not an ip) = %+v, want empty", got)
+    }
+}
+
+func TestOpenDisabled(t *testing.T) {
+    for _, path := range []string{"", "testdata/missing.mmdb"} {
+        resolver, err := Open(path, false)
+        if err != nil || resolver != nil {
+            t.Fatalf("Open(%q) = %v, %v; want nil, nil", path, resolver, err)
+        }
+        if got := resolver.Lookup("127.0.0.1"); got != (Location{}) {
+            t.Fatalf("nil resolver Lookup = %+v, want empty", got)
+        }
+    }
+}
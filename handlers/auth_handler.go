@@ -7,11 +7,10 @@ import (
     "golang.org/x/crypto/bcrypt"
     
     "github.com/heydeepakch/url-shortner-golang/models"
-    "github.com/heydeepakch/url-shortner-golang/storage"
     "github.com/heydeepakch/url-shortner-golang/utils"
 )
 
-func Register(c *gin.Context) {
+func (s *Server) Register(c *gin.Context) {
     var req models.RegisterRequest
     
     if err := c.ShouldBindJSON(&req); err != nil {
@@ -39,7 +38,7 @@ func Register(c *gin.Context) {
         PasswordHash: string(hashedPassword),
     }
     
-    if err := storage.CreateUser(user); err != nil {
+    if err := s.Users.CreateUser(user); err != nil {
         c.JSON(http.StatusConflict, gin.H{
             "error": "Username or email already exists",
         })
@@ -60,7 +59,7 @@ func Register(c *gin.Context) {
     })
 }
 
-func Login(c *gin.Context) {
+func (s *Server) Login(c *gin.Context) {
     var req models.LoginRequest
     
     if err := c.ShouldBindJSON(&req); err != nil {
@@ -70,7 +69,7 @@ func Login(c *gin.Context) {
         return
     }
     
-    user, err := storage.GetUserByEmail(req.Email)
+    user, err := s.Users.GetUserByEmail(req.Email)
     if err != nil {
         c.JSON(http.StatusUnauthorized, gin.H{
             "error": "Invalid email or password",
@@ -103,7 +102,7 @@ func Login(c *gin.Context) {
     })
 }
 			
-func GetProfile(c *gin.Context) {
+func (s *Server) GetProfile(c *gin.Context) {
     userID, exists := c.Get("user_id")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{
@@ -112,7 +111,7 @@ func GetProfile(c *gin.Context) {
         return
     }
     
-    user, err := storage.GetUserByID(userID.(int))
+    user, err := s.Users.GetUserByID(userID.(int))
     if err != nil {
         c.JSON(http.StatusNotFound, gin.H{
             "error": "User not found",
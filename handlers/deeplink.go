@@ -0,0 +1,151 @@
+package handlers
+
+import (
+    "html/template"
+    "net/http"
+    neturl "net/url"
+    "strings"
+    
+    "github.com/gin-gonic/gin"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+    "github.com/heydeepakch/url-shortner-golang/utils"
+)
+
+// appOpenTimeout is how long the bounce page waits for the app to take
+// over before falling back, in milliseconds
+const appOpenTimeout = 1500
+
+var bouncePage = template.Must(template.New("bounce").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Opening the app</title>
+<noscript><meta http-equiv="refresh" content="0;url={{.Fallback}}"></noscript>
+<style>
+body { font-family: system-ui, sans-serif; background: #f5f5f5; color: #111; display: flex; justify-content: center; padding-top: 15vh; margin: 0; }
+main { background: #fff; padding: 2rem; border-radius: 8px; box-shadow: 0 1px 4px rgba(0,0,0,.1); max-width: 22rem; text-align: center; }
+h1 { font-size: 1.2rem; margin: 0 0 .5rem; }
+p { color: #555; margin: 0; }
+</style>
+</head>
+<body>
+<main>
+<h1>Opening the app&hellip;</h1>
+<p>If nothing happens, <a href="{{.Fallback}}">continue here</a>.</p>
+</main>
+<script>
+(function () {
+    var fallback = {{.Fallback}};
+    var timer = setTimeout(function () {
+        window.location.replace(fallback);
+    }, {{.Timeout}});
+    // The app opening hides the page; don't fall back behind it
+    document.addEventListener("visibilitychange", function () {
+        if (document.hidden) {
+            clearTimeout(timer);
+        }
+    });
+    window.location.href = {{.AppURL}};
+})();
+</script>
+</body>
+</html>
+`))
+
+// deepLinkTarget returns the app target for the visitor's platform, or
+// nil when the link isn't a deep link or has none for it
+func deepLinkTarget(c *gin.Context, url *models.URL) *models.AppTarget {
+    if url.Type != models.URLTypeDeepLink || url.DeepLink == nil {
+        return nil
+    }
+    
+    // Phones get the bounce page and everyone else the redirect, so
+    // caches must not hand one to the other
+    c.Header("Vary", "User-Agent")
+    
+    switch utils.ParseUserAgent(c.Request.UserAgent()).OS {
+    case "iOS":
+        return url.DeepLink.IOS
+    case "Android":
+        return url.DeepLink.Android
+    }
+    return nil
+}
+
+// renderBouncePage tries to open the app, falling back to the store page
+// or, without one, to the web destination
+func renderBouncePage(c *gin.Context, target *models.AppTarget, destination string) {
+    fallback := target.StoreURL
+    if fallback == "" {
+        fallback = destination
+    }
+    
+    // The page navigates to the fallback from script, so anything but a
+    // web URL stored before destinations were checked must not render
+    if !webURL(fallback) {
+        linkErrorResponse(c, http.StatusBadGateway, "This link has an invalid destination", nil)
+        return
+    }
+    
+    // The page is per platform and counts a click each time it is shown
+    c.Header("Cache-Control", "no-store")
+    c.Header("Vary", "User-Agent")
+    c.Header("Content-Type", "text/html; charset=utf-8")
+    c.Status(http.StatusOK)
+    
+    bouncePage.Execute(c.Writer, gin.H{
+        "AppURL":   target.AppURL,
+        "Fallback": fallback,
+        "Timeout":  appOpenTimeout,
+    })
+}
+
+// validDeepLink checks a link's type and app targets agree, writing the
+// error response if not
+func validDeepLink(c *gin.Context, url *models.URL) bool {
+    if url.Type != models.URLTypeDeepLink {
+        if url.DeepLink != nil {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "error": "deep_link is only used with type deeplink",
+            })
+            return false
+        }
+        return true
+    }
+    
+    if url.DeepLink == nil || (url.DeepLink.IOS == nil && url.DeepLink.Android == nil) {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "A deeplink link needs an ios or android target",
+        })
+        return false
+    }
+    
+    for _, target := range []*models.AppTarget{url.DeepLink.IOS, url.DeepLink.Android} {
+        if target != nil && !safeAppURL(target.AppURL) {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "error": "app_url must use an app scheme or https",
+            })
+            return false
+        }
+    }
+    
+    return true
+}
+
+// safeAppURL rejects schemes that would run or embed content in the
+// bounce page instead of opening an app
+func safeAppURL(value string) bool {
+    parsed, err := neturl.Parse(value)
+    if err != nil || parsed.Scheme == "" {
+        return false
+    }
+    
+    switch strings.ToLower(parsed.Scheme) {
+    case "javascript", "data", "vbscript", "file", "blob", "about", "http":
+        return false
+    }
+    return true
+}
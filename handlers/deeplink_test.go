@@ -0,0 +1,109 @@
+package handlers
+
+import (
+    "net/http"
+    "strings"
+    "testing"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+func deepLinkURL(storeURL string) *models.URL {
+    return &models.URL{
+        ShortCode:   "app",
+        OriginalURL: "https://example.com/item",
+        Type:        models.URLTypeDeepLink,
+        DeepLink: &models.DeepLink{
+            IOS: &models.AppTarget{AppURL: "myapp://item/1", StoreURL: storeURL},
+        },
+    }
+}
+
+func TestBouncePageOnPhone(t *testing.T) {
+    server, router := newTestServer(t)
+    if err := server.URLs.CreateURL(deepLinkURL("https://apps.apple.com/app/id1")); err != nil {
+        t.Fatal(err)
+    }
+    
+    w := get(router, "/app", iPhoneUA)
+    if w.Code != http.StatusOK {
+        t.Fatalf("status = %d, want the 200 bounce page", w.Code)
+    }
+    if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+        t.Errorf("Cache-Control = %q, want no-store", cc)
+    }
+    
+    body := w.Body.String()
+    if !strings.Contains(body, `"myapp://item/1"`) || !strings.Contains(body, `href="https://apps.apple.com/app/id1"`) {
+        t.Fatalf("bounce page lacks the app URL or store fallback:\n%s", body)
+    }
+}
+
+func TestDeepLinkRedirectsDesktopAndBots(t *testing.T) {
+    server, router := newTestServer(t)
+    if err := server.URLs.CreateURL(deepLinkURL("")); err != nil {
+        t.Fatal(err)
+    }
+    
+    botUA := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+    for _, userAgent := range []string{desktopUA, botUA} {
+        w := get(router, "/app", userAgent)
+        if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/item" {
+            t.Errorf("%s: got %d to %q, want a redirect to the web destination", userAgent, w.Code, w.Header().Get("Location"))
+        }
+    }
+}
+
+func TestBouncePageRefusesUnsafeFallback(t *testing.T) {
+    c, w := newTestContext()
+    c.Request.Header.Set("Accept", "text/html")
+    
+    renderBouncePage(c, &models.AppTarget{AppURL: "myapp://x", StoreURL: "javascript:alert(1)"}, "https://example.com")
+    if w.Code != http.StatusBadGateway {
+        t.Fatalf("status = %d, want 502", w.Code)
+    }
+    if strings.Contains(w.Body.String(), "alert(1)") {
+        t.Fatal("unsafe fallback was rendered")
+    }
+}
+
+func TestValidDeepLink(t *testing.T) {
+    tests := []struct {
+        name string
+        url  *models.URL
+        want bool
+    }{
+        {"redirect", &models.URL{Type: models.URLTypeRedirect}, true},
+        {"ios target", deepLinkURL(""), true},
+        {"no targets", &models.URL{Type: models.URLTypeDeepLink, DeepLink: &models.DeepLink{}}, false},
+        {"deep_link on a redirect", &models.URL{Type: models.URLTypeRedirect, DeepLink: &models.DeepLink{}}, false},
+        {"javascript app url", &models.URL{
+            Type:     models.URLTypeDeepLink,
+            DeepLink: &models.DeepLink{Android: &models.AppTarget{AppURL: "javascript:alert(1)"}},
+        }, false},
+    }
+    
+    for _, tt := range tests {
+        c, _ := newTestContext()
+        if got := validDeepLink(c, tt.url); got != tt.want {
+            t.Errorf("%s: validDeepLink = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestSafeAppURL(t *testing.T) {
+    for value, want := range map[string]bool{
+        "myapp://item/1":           true,
+        "https://example.com/item": true,
+        "intent://item#Intent;end": true,
+        "http://example.com":       false,
+        "javascript:alert(1)":      false,
+        "JavaScript:alert(1)":      false,
+        "data:text/html,hi":        false,
+        "no-scheme":                false,
+    } {
+        if got := safeAppURL(value); got != want {
+            t.Errorf("safeAppURL(%q) = %v, want %v", value, got, want)
+        }
+    }
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+    "errors"
+    "html/template"
+    "net/http"
+    "strconv"
+    "time"
+    
+    "github.com/gin-gonic/gin"
+    
+    "github.com/heydeepakch/url-shortner-golang/config"
+    "github.com/heydeepakch/url-shortner-golang/storage"
+)
+
+var linkStatusPage = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Message}}</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #f5f5f5; color: #111; display: flex; justify-content: center; padding-top: 15vh; margin: 0; }
+main { background: #fff; padding: 2rem; border-radius: 8px; box-shadow: 0 1px 4px rgba(0,0,0,.1); max-width: 26rem; text-align: center; }
+h1 { font-size: 1.2rem; margin: 0 0 .5rem; }
+p { color: #555; margin: 0; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Message}}</h1>
+<p>{{.Status}} {{.StatusText}}</p>
+</main>
+</body>
+</html>
+`))
+
+// linkUnavailable answers a visitor whose link can't be followed, using
+// the reason reported by the store
+func linkUnavailable(c *gin.Context, err error) {
+    var notYetActive *storage.NotYetActiveError
+    
+    switch {
+    case errors.Is(err, storage.ErrNotFound):
+        linkErrorResponse(c, http.StatusNotFound, "This link does not exist", nil)
+    case errors.Is(err, storage.ErrExpired):
+        linkErrorResponse(c, http.StatusGone, "This link has expired", nil)
+    case errors.Is(err, storage.ErrDeleted):
+        linkErrorResponse(c, http.StatusGone, "This link has been deleted", nil)
+    case errors.Is(err, storage.ErrPaused):
+        pausedResponse(c)
+    case errors.As(err, &notYetActive):
+        notYetActiveResponse(c, notYetActive.StartsAt)
+    default:
+        linkErrorResponse(c, http.StatusInternalServerError, "Failed to resolve URL", nil)
+    }
+}
+
+// linkErrorResponse writes a message for someone following a short link:
+// a small HTML page for browsers, JSON with any details for API clients
+func linkErrorResponse(c *gin.Context, status int, message string, details gin.H) {
+    c.Header("Cache-Control", "no-store")
+    
+    if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+        c.Header("Content-Type", "text/html; charset=utf-8")
+        c.Status(status)
+        
+        linkStatusPage.Execute(c.Writer, gin.H{
+            "Message":    message,
+            "Status":     status,
+            "StatusText": http.StatusText(status),
+        })
+        return
+    }
+    
+    body := gin.H{"error": message}
+    for key, value := range details {
+        body[key] = value
+    }
+    c.JSON(status, body)
+}
+
+// notYetActiveResponse tells visitors of a scheduled link when it goes live
+func notYetActiveResponse(c *gin.Context, startsAt time.Time) {
+    wait := int(time.Until(startsAt).Seconds()) + 1
+    c.Header("Retry-After", strconv.Itoa(wait))
+    
+    linkErrorResponse(c, http.StatusServiceUnavailable, "This link is not active yet", gin.H{
+        "starts_at": startsAt,
+    })
+}
+
+// pausedResponse sends visitors of a paused link to the configured page,
+// or answers 503 with the configured message when there is none
+func pausedResponse(c *gin.Context) {
+    if config.AppConfig.PausedRedirectURL != "" {
+        c.Redirect(http.StatusFound, config.AppConfig.PausedRedirectURL)
+        return
+    }
+    
+    linkErrorResponse(c, http.StatusServiceUnavailable, config.AppConfig.PausedMessage, nil)
+}
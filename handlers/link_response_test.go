@@ -0,0 +1,83 @@
+package handlers
+
+import (
+    "fmt"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+    
+    "github.com/heydeepakch/url-shortner-golang/config"
+    "github.com/heydeepakch/url-shortner-golang/storage"
+)
+
+func TestLinkUnavailableStatus(t *testing.T) {
+    tests := []struct {
+        err  error
+        want int
+    }{
+        {storage.ErrNotFound, http.StatusNotFound},
+        {storage.ErrExpired, http.StatusGone},
+        {storage.ErrDeleted, http.StatusGone},
+        {storage.ErrPaused, http.StatusServiceUnavailable},
+        {&storage.NotYetActiveError{StartsAt: time.Now().Add(time.Hour)}, http.StatusServiceUnavailable},
+        {fmt.Errorf("lookup: %w", storage.ErrExpired), http.StatusGone},
+        {fmt.Errorf("connection refused"), http.StatusInternalServerError},
+    }
+    
+    for _, tt := range tests {
+        c, w := newTestContext()
+        config.AppConfig.PausedMessage = "This link is paused"
+        c.Request.Header.Set("Accept", "application/json")
+        
+        linkUnavailable(c, tt.err)
+        if w.Code != tt.want {
+            t.Errorf("linkUnavailable(%v) status = %d, want %d", tt.err, w.Code, tt.want)
+        }
+        if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+            t.Errorf("linkUnavailable(%v) Cache-Control = %q, want no-store", tt.err, cc)
+        }
+    }
+}
+
+func TestLinkErrorResponseNegotiates(t *testing.T) {
+    c, w := newTestContext()
+    c.Request.Header.Set("Accept", "text/html,application/xhtml+xml")
+    linkUnavailable(c, storage.ErrExpired)
+    if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+        t.Fatalf("browser got Content-Type %q, want text/html", ct)
+    }
+    if !strings.Contains(w.Body.String(), "This link has expired") {
+        t.Fatal("HTML page does not explain the link expired")
+    }
+    
+    c, w = newTestContext()
+    c.Request.Header.Set("Accept", "application/json")
+    linkUnavailable(c, storage.ErrExpired)
+    if body := w.Body.String(); body != `{"error":"This link has expired"}` {
+        t.Fatalf("API client got %s", body)
+    }
+}
+
+func TestNotYetActiveSetsRetryAfter(t *testing.T) {
+    c, w := newTestContext()
+    c.Request.Header.Set("Accept", "application/json")
+    
+    linkUnavailable(c, &storage.NotYetActiveError{StartsAt: time.Now().Add(time.Minute)})
+    if retry := w.Header().Get("Retry-After"); retry != "60" && retry != "61" {
+        t.Fatalf("Retry-After = %q, want about 60", retry)
+    }
+    if !strings.Contains(w.Body.String(), `"starts_at"`) {
+        t.Fatalf("body %s has no starts_at", w.Body)
+    }
+}
+
+func TestPausedRedirectsWhenConfigured(t *testing.T) {
+    c, w := newTestContext()
+    config.AppConfig.PausedRedirectURL = "https://example.com/paused"
+    
+    linkUnavailable(c, storage.ErrPaused)
+    if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/paused" {
+        t.Fatalf("paused link answered %d to %q", w.Code, w.Header().Get("Location"))
+    }
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+    "html/template"
+    "net/http"
+    "strconv"
+    "time"
+    
+    "github.com/gin-gonic/gin"
+    "golang.org/x/crypto/bcrypt"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+    "github.com/heydeepakch/url-shortner-golang/utils"
+)
+
+const (
+    // linkAccessTTL is how long a correct password unlocks a link
+    linkAccessTTL = 30 * time.Minute
+    
+    // Password attempts per link and client network within the window
+    passwordAttemptLimit  = 5
+    passwordAttemptWindow = 15 * time.Minute
+)
+
+var passwordPage = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Password required</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #f5f5f5; display: flex; justify-content: center; padding-top: 15vh; margin: 0; }
+form { background: #fff; padding: 2rem; border-radius: 8px; box-shadow: 0 1px 4px rgba(0,0,0,.1); width: 18rem; }
+h1 { font-size: 1.1rem; margin: 0 0 1rem; }
+input, button { width: 100%; box-sizing: border-box; padding: .6rem; margin-top: .5rem; font-size: 1rem; }
+button { background: #2563eb; color: #fff; border: 0; border-radius: 4px; cursor: pointer; }
+p { color: #b91c1c; font-size: .9rem; margin: .5rem 0 0; }
+</style>
+</head>
+<body>
+<form method="post" action="/{{.ShortCode}}">
+<h1>This link is password protected</h1>
+<input type="password" name="password" placeholder="Password" autofocus required>
+<button type="submit">Continue</button>
+{{if .Error}}<p>{{.Error}}</p>{{end}}
+</form>
+</body>
+</html>
+`))
+
+// renderPasswordForm serves the password prompt for shortCode
+func renderPasswordForm(c *gin.Context, shortCode string, status int, message string) {
+    c.Header("Cache-Control", "no-store")
+    c.Header("Content-Type", "text/html; charset=utf-8")
+    c.Status(status)
+    
+    passwordPage.Execute(c.Writer, gin.H{
+        "ShortCode": shortCode,
+        "Error":     message,
+    })
+}
+
+// UnlockURL checks a password submitted from the prompt and, if it
+// matches, remembers that in a signed cookie and follows the link
+func (s *Server) UnlockURL(c *gin.Context) {
+    url, ok := s.resolveURL(c, c.Param("code"))
+    if !ok {
+        return
+    }
+    
+    if url.PasswordHash == "" {
+        c.Redirect(http.StatusSeeOther, "/"+url.ShortCode)
+        return
+    }
+    
+    // Attempts are limited per client network, using the same anonymised
+    // address as analytics
+    client := utils.HashIP(c.ClientIP())
+    attempts, err := s.Cache.AddPasswordAttempt(url.ShortCode, client, passwordAttemptWindow)
+    if err == nil && attempts > passwordAttemptLimit {
+        c.Header("Retry-After", strconv.Itoa(int(passwordAttemptWindow.Seconds())))
+        renderPasswordForm(c, url.ShortCode, http.StatusTooManyRequests, "Too many attempts, try again later")
+        return
+    }
+    
+    err = bcrypt.CompareHashAndPassword(
+        []byte(url.PasswordHash),
+        []byte(c.PostForm("password")),
+    )
+    if err != nil {
+        renderPasswordForm(c, url.ShortCode, http.StatusUnauthorized, "Incorrect password")
+        return
+    }
+    
+    // The cookie is named per link but sent site-wide so the preview at
+    // /code+ sees it as well as /code
+    expires := time.Now().Add(linkAccessTTL)
+    c.SetSameSite(http.SameSiteLaxMode)
+    c.SetCookie(
+        linkAccessCookie(url.ShortCode),
+        utils.SignLinkAccess(url.ShortCode, url.PasswordHash, expires),
+        int(linkAccessTTL.Seconds()),
+        "/",
+        "",
+        secureCookies(),
+        true,
+    )
+    
+    s.followURL(c, url, http.StatusSeeOther)
+}
+
+// hasLinkAccess reports whether the request carries a valid unlock cookie
+func hasLinkAccess(c *gin.Context, url *models.URL) bool {
+    value, err := c.Cookie(linkAccessCookie(url.ShortCode))
+    if err != nil {
+        return false
+    }
+    return utils.VerifyLinkAccess(value, url.ShortCode, url.PasswordHash)
+}
+
+func linkAccessCookie(shortCode string) string {
+    return "link_access_" + shortCode
+}
+
+func hashLinkPassword(password string) (string, error) {
+    hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    return string(hash), err
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+    "html/template"
+    "net/http"
+    "strings"
+    
+    "github.com/gin-gonic/gin"
+    
+    "github.com/heydeepakch/url-shortner-golang/config"
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Link preview</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #f5f5f5; color: #111; display: flex; justify-content: center; padding-top: 15vh; margin: 0; }
+main { background: #fff; padding: 2rem; border-radius: 8px; box-shadow: 0 1px 4px rgba(0,0,0,.1); width: 28rem; max-width: 90vw; }
+h1 { font-size: 1.2rem; margin: 0 0 1rem; }
+dl { margin: 0 0 1.5rem; }
+dt { color: #555; font-size: .85rem; margin-top: .75rem; }
+dd { margin: .2rem 0 0; word-break: break-all; }
+a.button { display: inline-block; background: #2563eb; color: #fff; padding: .6rem 1.2rem; border-radius: 4px; text-decoration: none; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{if .Title}}{{.Title}}{{else}}Link preview{{end}}</h1>
+<dl>
+<dt>Short link</dt>
+<dd>{{.ShortURL}}</dd>
+<dt>Destination</dt>
+<dd>{{if .OriginalURL}}{{.OriginalURL}}{{else}}Hidden, this link is password protected{{end}}</dd>
+<dt>Created</dt>
+<dd>{{.CreatedAt.Format "2 January 2006"}}</dd>
+<dt>Clicks</dt>
+<dd>{{.Clicks}}</dd>
+</dl>
+<a class="button" href="/{{.ShortCode}}" rel="nofollow">Continue</a>
+</main>
+</body>
+</html>
+`))
+
+// previewCode reads the code from the route, reporting whether the
+// visitor asked to preview the link (code+ or ?preview=1) instead of
+// following it
+func previewCode(c *gin.Context) (string, bool) {
+    code := c.Param("code")
+    if strings.HasSuffix(code, "+") {
+        return strings.TrimSuffix(code, "+"), true
+    }
+    return code, c.Query("preview") == "1"
+}
+
+// renderPreview describes a link without redirecting or counting a click
+func (s *Server) renderPreview(c *gin.Context, url *models.URL) {
+    // Cached links carry no click count, so it is read from the store
+    clicks := url.Clicks
+    s.Clicks.Read(func() {
+        if stored, err := s.URLs.GetURL(url.ShortCode); err == nil {
+            clicks = stored.Clicks
+        }
+        clicks += s.Clicks.Pending(url.ShortCode).Human
+    })
+    
+    preview := models.LinkPreviewResponse{
+        ShortCode: url.ShortCode,
+        ShortURL:  config.AppConfig.BaseURL + "/" + url.ShortCode,
+        Title:     url.Title,
+        CreatedAt: url.CreatedAt,
+        Clicks:    clicks,
+        Protected: url.PasswordHash != "",
+    }
+    
+    // The destination of a protected link is only shown once unlocked
+    if !preview.Protected || hasLinkAccess(c, url) {
+        preview.OriginalURL = url.OriginalURL
+    }
+    
+    c.Header("Cache-Control", "no-cache")
+    
+    if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+        c.Header("Content-Type", "text/html; charset=utf-8")
+        c.Status(http.StatusOK)
+        previewPage.Execute(c.Writer, preview)
+        return
+    }
+    
+    c.JSON(http.StatusOK, preview)
+}
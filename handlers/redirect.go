@@ -0,0 +1,188 @@
+package handlers
+
+import (
+    "fmt"
+    "net/http"
+    neturl "net/url"
+    "strings"
+    "time"
+    
+    "github.com/gin-gonic/gin"
+    
+    "github.com/heydeepakch/url-shortner-golang/config"
+    "github.com/heydeepakch/url-shortner-golang/models"
+    "github.com/heydeepakch/url-shortner-golang/utils"
+)
+
+// redirectStatus is the status a link redirects with: the owner's choice,
+// or the server default
+func redirectStatus(url *models.URL) int {
+    if url.RedirectStatus != nil {
+        return *url.RedirectStatus
+    }
+    return config.AppConfig.DefaultRedirectStatus
+}
+
+// temporaryStatus swaps a permanent redirect for the temporary one with
+// the same method handling
+func temporaryStatus(status int) int {
+    switch status {
+    case http.StatusMovedPermanently:
+        return http.StatusFound
+    case http.StatusPermanentRedirect:
+        return http.StatusTemporaryRedirect
+    }
+    return status
+}
+
+// redirectCacheControl is the Cache-Control header sent with a redirect.
+// Permanent redirects may be cached, but no longer than the link lives,
+// and geo targeted, A/B split or deep links only by the visitor's own
+// browser. Temporary redirects are revalidated so every visit reaches us
+// and is counted. Click-limited and password links are never cached.
+func redirectCacheControl(url *models.URL, status int) string {
+    if temporaryStatus(status) == status {
+        return "no-cache"
+    }
+    
+    maxAge := config.AppConfig.PermanentRedirectMaxAge
+    if url.ExpiresAt != nil {
+        if remaining := int(time.Until(*url.ExpiresAt).Seconds()); remaining < maxAge {
+            maxAge = remaining
+        }
+    }
+    if maxAge <= 0 {
+        return "no-cache"
+    }
+    
+    scope := "public"
+    if len(url.GeoTargeting) > 0 || len(url.Variants) > 0 || url.Type == models.URLTypeDeepLink {
+        scope = "private"
+    }
+    
+    return fmt.Sprintf("%s, max-age=%d", scope, maxAge)
+}
+
+// targetURL picks the destination for this visitor: the first targeting
+// rule matching their User-Agent, then the destination for their
+// country, then their A/B variant, otherwise the link's own URL. The
+// variant name is returned when the split decided.
+func (s *Server) targetURL(c *gin.Context, url *models.URL) (string, string) {
+    if len(url.Targeting) > 0 {
+        // The destination depends on the User-Agent, so caches must not
+        // hand one visitor's redirect to another
+        c.Header("Vary", "User-Agent")
+        
+        ua := utils.ParseUserAgent(c.Request.UserAgent())
+        for _, rule := range url.Targeting {
+            if rule.OS != "" && !strings.EqualFold(rule.OS, ua.OS) {
+                continue
+            }
+            if rule.Device != "" && rule.Device != ua.Device {
+                continue
+            }
+            return rule.URL, ""
+        }
+    }
+    
+    if len(url.GeoTargeting) > 0 {
+        country := s.Geo.Lookup(c.ClientIP()).Country
+        if destination, ok := url.GeoTargeting[country]; ok {
+            return destination, ""
+        }
+    }
+    
+    if len(url.Variants) > 0 {
+        variant := assignVariant(c, url)
+        return variant.URL, variant.Name
+    }
+    
+    return url.OriginalURL, ""
+}
+
+// webURL reports whether value is an absolute http or https URL. The
+// url binding tag also accepts schemes such as javascript:, which must
+// never reach a visitor's browser.
+func webURL(value string) bool {
+    parsed, err := neturl.Parse(value)
+    if err != nil || parsed.Host == "" {
+        return false
+    }
+    
+    scheme := strings.ToLower(parsed.Scheme)
+    return scheme == "http" || scheme == "https"
+}
+
+// validDestinations checks every place a link can send visitors is an
+// http or https URL, writing the error response if one isn't
+func validDestinations(c *gin.Context, url *models.URL) bool {
+    destinations := []string{url.OriginalURL}
+    for _, rule := range url.Targeting {
+        destinations = append(destinations, rule.URL)
+    }
+    for _, destination := range url.GeoTargeting {
+        destinations = append(destinations, destination)
+    }
+    for _, variant := range url.Variants {
+        destinations = append(destinations, variant.URL)
+    }
+    if url.DeepLink != nil {
+        for _, target := range []*models.AppTarget{url.DeepLink.IOS, url.DeepLink.Android} {
+            if target != nil && target.StoreURL != "" {
+                destinations = append(destinations, target.StoreURL)
+            }
+        }
+    }
+    
+    for _, destination := range destinations {
+        if !webURL(destination) {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "error": fmt.Sprintf("Destination %q must be an http or https URL", destination),
+            })
+            return false
+        }
+    }
+    
+    return true
+}
+
+// secureCookies reports whether cookies should be limited to HTTPS,
+// which is the case when the short domain is served over it
+func secureCookies() bool {
+    return strings.HasPrefix(config.AppConfig.BaseURL, "https://")
+}
+
+// normalizeGeoTargeting upper-cases country codes to match GeoIP results
+func normalizeGeoTargeting(destinations map[string]string) map[string]string {
+    if len(destinations) == 0 {
+        return nil
+    }
+    
+    normalized := make(map[string]string, len(destinations))
+    for country, destination := range destinations {
+        normalized[strings.ToUpper(country)] = destination
+    }
+    return normalized
+}
+
+// validTargeting checks targeting rules beyond what binding covers,
+// writing the error response if one is unusable
+func validTargeting(c *gin.Context, rules []models.TargetingRule) bool {
+    for i, rule := range rules {
+        if rule.OS == "" && rule.Device == "" {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "error": fmt.Sprintf("Targeting rule %d needs an os or device condition", i+1),
+            })
+            return false
+        }
+        
+        if rule.OS != "" && !utils.KnownOS(rule.OS) {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "error": fmt.Sprintf("Targeting rule %d has an unknown os %q", i+1, rule.OS),
+            })
+            return false
+        }
+    }
+    
+    return true
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+    
+    "github.com/gin-gonic/gin"
+    
+    "github.com/heydeepakch/url-shortner-golang/config"
+    "github.com/heydeepakch/url-shortner-golang/geoip"
+    "github.com/heydeepakch/url-shortner-golang/models"
+    "github.com/heydeepakch/url-shortner-golang/storage"
+)
+
+const (
+    desktopUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+    iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+)
+
+func setRedirectConfig() {
+    config.AppConfig = &config.Config{
+        BaseURL:                 "https://sho.rt",
+        DefaultRedirectStatus:   302,
+        PermanentRedirectMaxAge: 86400,
+    }
+}
+
+// newTestServer returns a Server on in-memory stores with the redirect
+// route registered
+func newTestServer(t *testing.T) (*Server, *gin.Engine) {
+    t.Helper()
+    
+    gin.SetMode(gin.TestMode)
+    setRedirectConfig()
+    
+    store := storage.NewMemoryStore()
+    cache := storage.NewURLCache(storage.NewMemoryCache(100))
+    server := NewServer(
+        store,
+        store,
+        cache,
+        storage.NewClickBuffer(store, cache),
+        storage.NewClickEventWriter(store, 100, 10, time.Hour),
+        store,
+        nil,
+    )
+    
+    router := gin.New()
+    router.GET("/:code", server.RedirectURL)
+    return server, router
+}
+
+func get(router *gin.Engine, path, userAgent string) *httptest.ResponseRecorder {
+    w := httptest.NewRecorder()
+    req := httptest.NewRequest(http.MethodGet, path, nil)
+    req.Header.Set("User-Agent", userAgent)
+    router.ServeHTTP(w, req)
+    return w
+}
+
+func TestClickLimitedRedirectIsNeverCached(t *testing.T) {
+    server, router := newTestServer(t)
+    
+    permanent := http.StatusMovedPermanently
+    maxClicks := int64(1)
+    url := &models.URL{
+        ShortCode:      "once",
+        OriginalURL:    "https://example.com",
+        RedirectStatus: &permanent,
+        MaxClicks:      &maxClicks,
+    }
+    if err := server.URLs.CreateURL(url); err != nil {
+        t.Fatal(err)
+    }
+    
+    w := get(router, "/once", desktopUA)
+    if w.Code != http.StatusFound {
+        t.Fatalf("status = %d, want 302 for a click-limited link", w.Code)
+    }
+    if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+        t.Fatalf("Cache-Control = %q, want no-store", cc)
+    }
+    
+    if w := get(router, "/once", desktopUA); w.Code != http.StatusGone {
+        t.Fatalf("second visit status = %d, want 410", w.Code)
+    }
+}
+
+func TestDeepLinkRedirectIsNotShared(t *testing.T) {
+    url := &models.URL{
+        ShortCode:   "app",
+        OriginalURL: "https://example.com",
+        Type:        models.URLTypeDeepLink,
+        DeepLink:    &models.DeepLink{IOS: &models.AppTarget{AppURL: "myapp://open"}},
+    }
+    
+    c, w := newTestContext()
+    setRedirectConfig()
+    c.Request.Header.Set("User-Agent", desktopUA)
+    
+    if target := deepLinkTarget(c, url); target != nil {
+        t.Fatalf("desktop visitor got app target %+v", target)
+    }
+    if vary := w.Header().Get("Vary"); vary != "User-Agent" {
+        t.Errorf("Vary = %q, want User-Agent", vary)
+    }
+    
+    for _, status := range []int{301, 308} {
+        if cc := redirectCacheControl(url, status); !strings.HasPrefix(cc, "private") {
+            t.Errorf("Cache-Control for a %d deep link = %q, want private", status, cc)
+        }
+    }
+}
+
+func TestRedirectStatus(t *testing.T) {
+    setRedirectConfig()
+    
+    if got := redirectStatus(&models.URL{}); got != http.StatusFound {
+        t.Errorf("default redirectStatus = %d, want the configured 302", got)
+    }
+    permanent := http.StatusPermanentRedirect
+    if got := redirectStatus(&models.URL{RedirectStatus: &permanent}); got != permanent {
+        t.Errorf("redirectStatus = %d, want the link's own 308", got)
+    }
+    
+    for status, want := range map[int]int{301: 302, 308: 307, 302: 302, 307: 307} {
+        if got := temporaryStatus(status); got != want {
+            t.Errorf("temporaryStatus(%d) = %d, want %d", status, got, want)
+        }
+    }
+}
+
+func TestRedirectCacheControl(t *testing.T) {
+    setRedirectConfig()
+    
+    soon := time.Now().Add(time.Hour)
+    past := time.Now().Add(-time.Minute)
+    
+    tests := []struct {
+        name   string
+        url    *models.URL
+        status int
+        want   string
+    }{
+        {"temporary", &models.URL{}, 302, "no-cache"},
+        {"temporary 307", &models.URL{}, 307, "no-cache"},
+        {"permanent", &models.URL{}, 301, "public, max-age=86400"},
+        {"permanent 308", &models.URL{}, 308, "public, max-age=86400"},
+        {"capped by expiry", &models.URL{ExpiresAt: &soon}, 301, "public, max-age=3599"},
+        {"expired", &models.URL{ExpiresAt: &past}, 301, "no-cache"},
+        {"geo targeted", &models.URL{GeoTargeting: map[string]string{"DE": "https://example.de"}}, 301, "private, max-age=86400"},
+        {"a/b split", &models.URL{Variants: splitURL(1, 1).Variants}, 308, "private, max-age=86400"},
+    }
+    
+    for _, tt := range tests {
+        got := redirectCacheControl(tt.url, tt.status)
+        
+        // The expiry cap is computed from the clock, so allow a second
+        if tt.name == "capped by expiry" && got == "public, max-age=3600" {
+            continue
+        }
+        if got != tt.want {
+            t.Errorf("%s: redirectCacheControl = %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestPermanentRedirectEndToEnd(t *testing.T) {
+    server, router := newTestServer(t)
+    
+    permanent := http.StatusMovedPermanently
+    if err := server.URLs.CreateURL(&models.URL{ShortCode: "home", OriginalURL: "https://example.com", RedirectStatus: &permanent}); err != nil {
+        t.Fatal(err)
+    }
+    
+    w := get(router, "/home", desktopUA)
+    if w.Code != permanent || w.Header().Get("Location") != "https://example.com" {
+        t.Fatalf("redirect = %d to %q", w.Code, w.Header().Get("Location"))
+    }
+    if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=86400" {
+        t.Fatalf("Cache-Control = %q", cc)
+    }
+}
+
+func TestTargetURLDeviceRules(t *testing.T) {
+    server, _ := newTestServer(t)
+    
+    androidUA := "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36"
+    url := &models.URL{
+        ShortCode:   "app",
+        OriginalURL: "https://example.com",
+        Targeting: []models.TargetingRule{
+            {OS: "ios", URL: "https://apps.apple.com/app"},
+            {OS: "Android", Device: "mobile", URL: "https://play.google.com/app"},
+            {Device: "mobile", URL: "https://m.example.com"},
+        },
+    }
+    
+    tests := []struct {
+        name      string
+        userAgent string
+        want      string
+    }{
+        {"os matched case-insensitively", iPhoneUA, "https://apps.apple.com/app"},
+        {"os and device", androidUA, "https://play.google.com/app"},
+        {"no rule matches", desktopUA, "https://example.com"},
+    }
+    
+    for _, tt := range tests {
+        c, w := newTestContext()
+        c.Request.Header.Set("User-Agent", tt.userAgent)
+        
+        if got, variant := server.targetURL(c, url); got != tt.want || variant != "" {
+            t.Errorf("%s: targetURL = %q, %q; want %q", tt.name, got, variant, tt.want)
+        }
+        if vary := w.Header().Get("Vary"); vary != "User-Agent" {
+            t.Errorf("%s: Vary = %q, want User-Agent", tt.name, vary)
+        }
+    }
+}
+
+func TestValidTargetingRejectsEmptyRule(t *testing.T) {
+    c, w := newTestContext()
+    if validTargeting(c, []models.TargetingRule{{URL: "https://example.com"}}) {
+        t.Fatal("validTargeting accepted a rule matching every visitor")
+    }
+    if w.Code != http.StatusBadRequest {
+        t.Fatalf("status = %d, want 400", w.Code)
+    }
+}
+
+func TestValidTargetingRejectsUnknownOS(t *testing.T) {
+    c, _ := newTestContext()
+    if validTargeting(c, []models.TargetingRule{{OS: "BeOS", URL: "https://example.com"}}) {
+        t.Fatal("validTargeting accepted an unknown os")
+    }
+}
+
+func TestGeoTargetedRedirect(t *testing.T) {
+    server, router := newTestServer(t)
+    
+    geo, err := geoip.Open("../geoip/testdata/country.mmdb", false)
+    if err != nil {
+        t.Fatal(err)
+    }
+    server.Geo = geo
+    
+    // Only requests from the load balancer may name the client
+    if err := router.SetTrustedProxies([]string{"10.0.0.0/8"}); err != nil {
+        t.Fatal(err)
+    }
+    
+    url := &models.URL{
+        ShortCode:    "geo",
+        OriginalURL:  "https://example.com",
+        GeoTargeting: normalizeGeoTargeting(map[string]string{"de": "https://example.de"}),
+    }
+    if err := server.URLs.CreateURL(url); err != nil {
+        t.Fatal(err)
+    }
+    
+    tests := []struct {
+        name       string
+        remoteAddr string
+        forwarded  string
+        want       string
+    }{
+        {"direct visitor in DE", "127.0.0.1:4000", "", "https://example.de"},
+        {"direct visitor elsewhere", "192.0.2.1:4000", "", "https://example.com"},
+        {"via trusted proxy", "10.0.0.2:4000", "127.0.0.1", "https://example.de"},
+        {"spoofed header", "192.0.2.1:4000", "127.0.0.1", "https://example.com"},
+    }
+    
+    for _, tt := range tests {
+        w := httptest.NewRecorder()
+        req := httptest.NewRequest(http.MethodGet, "/geo", nil)
+        req.RemoteAddr = tt.remoteAddr
+        req.Header.Set("User-Agent", desktopUA)
+        if tt.forwarded != "" {
+            req.Header.Set("X-Forwarded-For", tt.forwarded)
+        }
+        router.ServeHTTP(w, req)
+        
+        if got := w.Header().Get("Location"); got != tt.want {
+            t.Errorf("%s: redirected to %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
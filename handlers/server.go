@@ -0,0 +1,41 @@
+package handlers
+
+import (
+    "github.com/heydeepakch/url-shortner-golang/geoip"
+    "github.com/heydeepakch/url-shortner-golang/storage"
+)
+
+// Server holds the dependencies shared by the HTTP handlers
+type Server struct {
+    URLs   storage.URLStore
+    Users  storage.UserStore
+    Cache  *storage.URLCache
+    Clicks *storage.ClickBuffer
+    Events *storage.ClickEventWriter
+    
+    // Analytics answers click statistics queries
+    Analytics storage.ClickStore
+    
+    // Geo resolves client IPs to locations; nil when disabled
+    Geo *geoip.Resolver
+}
+
+func NewServer(
+    urls storage.URLStore,
+    users storage.UserStore,
+    cache *storage.URLCache,
+    clicks *storage.ClickBuffer,
+    events *storage.ClickEventWriter,
+    analytics storage.ClickStore,
+    geo *geoip.Resolver,
+) *Server {
+    return &Server{
+        URLs:      urls,
+        Users:     users,
+        Cache:     cache,
+        Clicks:    clicks,
+        Events:    events,
+        Analytics: analytics,
+        Geo:       geo,
+    }
+}
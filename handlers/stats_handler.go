@@ -0,0 +1,342 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+    "unicode/utf8"
+    
+    "github.com/gin-gonic/gin"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+    "github.com/heydeepakch/url-shortner-golang/storage"
+    "github.com/heydeepakch/url-shortner-golang/utils"
+)
+
+// defaultSpans is the range returned when "from" is omitted
+var defaultSpans = map[string]time.Duration{
+    "minute": time.Hour,
+    "hour":   24 * time.Hour,
+    "day":    30 * 24 * time.Hour,
+    "week":   12 * 7 * 24 * time.Hour,
+}
+
+// loadStatsURL fetches the link named in the route and checks the caller
+// may see its stats, writing the error response if not
+func (s *Server) loadStatsURL(c *gin.Context) (*models.URL, bool) {
+    url, err := s.URLs.GetURL(c.Param("code"))
+    if err != nil || url.DeletedAt != nil {
+        c.JSON(http.StatusNotFound, gin.H{
+            "error": "URL not found",
+        })
+        return nil, false
+    }
+    
+    // Stats of owned links are only for their owner; anonymous links
+    // have no owner and stay public
+    if url.UserID != nil {
+        userID, exists := c.Get("user_id")
+        if !exists {
+            c.JSON(http.StatusUnauthorized, gin.H{
+                "error": "Authentication required to view these stats",
+            })
+            return nil, false
+        }
+        
+        if *url.UserID != userID.(int) {
+            c.JSON(http.StatusForbidden, gin.H{
+                "error": "You don't have permission to view these stats",
+            })
+            return nil, false
+        }
+    }
+    
+    return url, true
+}
+
+// Column widths of the clicks table; longer values would make the whole
+// batch fail to insert
+const (
+    maxAcceptLanguage = 255
+    maxReferrerDomain = 255
+    maxBrowser        = 50
+    maxOS             = 50
+    maxDevice         = 20
+)
+
+// truncate cuts value to at most max characters, dropping NUL bytes and
+// invalid UTF-8 that Postgres would also reject
+func truncate(value string, max int) string {
+    value = strings.ToValidUTF8(strings.ReplaceAll(value, "\x00", ""), "\uFFFD")
+    if utf8.RuneCountInString(value) <= max {
+        return value
+    }
+    return string([]rune(value)[:max])
+}
+
+// recordClickEvent queues the analytics event for a redirect
+func (s *Server) recordClickEvent(c *gin.Context, shortCode string, bot bool, variant string) {
+    referrer := strings.ToValidUTF8(c.Request.Referer(), "\uFFFD")
+    userAgent := strings.ToValidUTF8(c.Request.UserAgent(), "\uFFFD")
+    ua := utils.ParseUserAgent(userAgent)
+    
+    // The IP is resolved here and only its anonymised hash is kept
+    clientIP := c.ClientIP()
+    location := s.Geo.Lookup(clientIP)
+    
+    // Email and QR code visits carry no Referer, so links printed or
+    // mailed can be tagged with ?utm_source=... instead
+    source := utils.ReferrerDomain(referrer)
+    if source == "" {
+        source = c.Query("utm_source")
+    }
+    
+    s.Events.Record(models.ClickEvent{
+        ShortCode:      shortCode,
+        ClickedAt:      time.Now(),
+        Referrer:       referrer,
+        UserAgent:      userAgent,
+        IPHash:         utils.HashIP(clientIP),
+        AcceptLanguage: truncate(c.GetHeader("Accept-Language"), maxAcceptLanguage),
+        IsBot:          bot,
+        ReferrerDomain: truncate(source, maxReferrerDomain),
+        Browser:        truncate(ua.Browser, maxBrowser),
+        OS:             truncate(ua.OS, maxOS),
+        Device:         truncate(ua.Device, maxDevice),
+        Country:        location.Country,
+        Region:         location.Region,
+        City:           location.City,
+        Variant:        variant,
+    })
+}
+
+// parseTimeParam accepts RFC3339 timestamps or plain dates, the latter
+// taken as midnight in loc
+func parseTimeParam(value string, loc *time.Location) (time.Time, error) {
+    if t, err := time.Parse(time.RFC3339, value); err == nil {
+        return t, nil
+    }
+    return time.ParseInLocation("2006-01-02", value, loc)
+}
+
+// GetURLTimeSeries returns zero-filled click counts per time bucket.
+// Query: granularity (minute|hour|day|week), from, to, tz (IANA name),
+// include_bots (default false).
+func (s *Server) GetURLTimeSeries(c *gin.Context) {
+    url, ok := s.loadStatsURL(c)
+    if !ok {
+        return
+    }
+    
+    granularity := c.DefaultQuery("granularity", "day")
+    if !utils.ValidGranularity(granularity) {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "granularity must be one of minute, hour, day, week",
+        })
+        return
+    }
+    
+    loc, fromParam, to, ok := parseRange(c)
+    if !ok {
+        return
+    }
+    
+    from := to.Add(-defaultSpans[granularity])
+    if fromParam != nil {
+        from = *fromParam
+    }
+    
+    from = utils.BucketStart(from, granularity, loc)
+    
+    // Reject oversized ranges before touching the click tables
+    if utils.TooManyBuckets(from, to, granularity, loc) {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "Time range too large for this granularity",
+        })
+        return
+    }
+    
+    filter := storage.ClickFilter{From: from, To: to, IncludeBots: includeBots(c)}
+    
+    // Hourly rollups are only usable when local buckets line up with UTC
+    // hours; otherwise fall back to the per-minute raw events
+    var counts []models.ClickBucket
+    var err error
+    if granularity == "minute" || !utils.HourAligned(from, to, loc) {
+        counts, err = s.Analytics.ClickCountsByMinute(url.ShortCode, filter)
+    } else {
+        counts, err = s.hourlyCounts(url.ShortCode, filter)
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "error": "Failed to load click statistics",
+        })
+        return
+    }
+    
+    buckets, err := utils.BuildTimeSeries(counts, from, to, granularity, loc)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "Time range too large for this granularity",
+        })
+        return
+    }
+    
+    var total int64
+    for _, bucket := range buckets {
+        total += bucket.Clicks
+    }
+    
+    c.JSON(http.StatusOK, models.TimeSeriesResponse{
+        ShortCode:   url.ShortCode,
+        Granularity: granularity,
+        Timezone:    loc.String(),
+        From:        from,
+        To:          to.In(loc),
+        IncludeBots: filter.IncludeBots,
+        Total:       total,
+        Buckets:     buckets,
+    })
+}
+
+// hourlyCounts reads whole hours from the rollups and the trailing
+// partial hour, if any, from the raw events so it is not over-counted
+func (s *Server) hourlyCounts(shortCode string, filter storage.ClickFilter) ([]models.ClickBucket, error) {
+    hourEnd := filter.To.UTC().Truncate(time.Hour)
+    if !hourEnd.After(filter.From) {
+        return s.Analytics.ClickCountsByMinute(shortCode, filter)
+    }
+    
+    whole := filter
+    whole.To = hourEnd
+    counts, err := s.Analytics.ClickCountsByHour(shortCode, whole)
+    if err != nil || !hourEnd.Before(filter.To) {
+        return counts, err
+    }
+    
+    partial := filter
+    partial.From = hourEnd
+    tail, err := s.Analytics.ClickCountsByMinute(shortCode, partial)
+    if err != nil {
+        return nil, err
+    }
+    return append(counts, tail...), nil
+}
+
+// GetURLBreakdown returns the top referrer domains, browsers, operating
+// systems, device classes, locations and A/B variants for a link.
+// Query: from, to (RFC3339 or YYYY-MM-DD), limit (default 10),
+// include_bots (default false).
+func (s *Server) GetURLBreakdown(c *gin.Context) {
+    url, ok := s.loadStatsURL(c)
+    if !ok {
+        return
+    }
+    
+    _, from, to, ok := parseRange(c)
+    if !ok {
+        return
+    }
+    
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    if err != nil || limit < 1 || limit > 100 {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "limit must be between 1 and 100",
+        })
+        return
+    }
+    
+    filter := storage.ClickFilter{To: to, IncludeBots: includeBots(c)}
+    if from != nil {
+        filter.From = *from
+    }
+    
+    response := models.BreakdownResponse{
+        ShortCode:   url.ShortCode,
+        From:        from,
+        To:          to,
+        IncludeBots: filter.IncludeBots,
+    }
+    
+    dimensions := []struct {
+        name    string
+        empty   string
+        entries *[]models.BreakdownEntry
+    }{
+        {"referrer", "direct", &response.Referrers},
+        {"browser", "unknown", &response.Browsers},
+        {"os", "unknown", &response.OS},
+        {"device", "unknown", &response.Devices},
+        {"country", "unknown", &response.Countries},
+        {"region", "unknown", &response.Regions},
+        {"city", "unknown", &response.Cities},
+        {"variant", "none", &response.Variants},
+    }
+    
+    for _, dimension := range dimensions {
+        entries, err := s.Analytics.ClickBreakdown(url.ShortCode, dimension.name, filter, limit)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{
+                "error": "Failed to load click statistics",
+            })
+            return
+        }
+        
+        for i := range entries {
+            if entries[i].Value == "" {
+                entries[i].Value = dimension.empty
+            }
+        }
+        
+        if entries == nil {
+            entries = []models.BreakdownEntry{}
+        }
+        *dimension.entries = entries
+    }
+    
+    c.JSON(http.StatusOK, response)
+}
+
+// includeBots reports whether the include_bots query parameter asks for
+// bot traffic to be counted alongside human clicks
+func includeBots(c *gin.Context) bool {
+    include, _ := strconv.ParseBool(c.Query("include_bots"))
+    return include
+}
+
+// parseRange reads the tz, from and to query parameters, with dates taken
+// in tz. A missing from is returned as nil, a missing to means now.
+func parseRange(c *gin.Context) (*time.Location, *time.Time, time.Time, bool) {
+    loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "Unknown timezone",
+        })
+        return nil, nil, time.Time{}, false
+    }
+    
+    to := time.Now()
+    if value := c.Query("to"); value != "" {
+        if to, err = parseTimeParam(value, loc); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "error": "Invalid 'to' time, use RFC3339 or YYYY-MM-DD",
+            })
+            return nil, nil, time.Time{}, false
+        }
+    }
+    
+    var from *time.Time
+    if value := c.Query("from"); value != "" {
+        t, err := parseTimeParam(value, loc)
+        if err != nil || !t.Before(to) {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "error": "Invalid 'from' time, use RFC3339 or YYYY-MM-DD before 'to'",
+            })
+            return nil, nil, time.Time{}, false
+        }
+        from = &t
+    }
+    
+    return loc, from, to, true
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+    "strings"
+    "testing"
+    "unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+    tests := []struct {
+        name  string
+        value string
+        max   int
+        want  string
+    }{
+        {"short", "en-US", 255, "en-US"},
+        {"long", strings.Repeat("a", 300), 255, strings.Repeat("a", 255)},
+        {"multibyte", "ééééé", 3, "ééé"},
+        {"nul", "en\x00-US", 255, "en-US"},
+        {"invalid utf-8", "en\xff", 255, "en�"},
+    }
+    
+    for _, tt := range tests {
+        got := truncate(tt.value, tt.max)
+        if got != tt.want || !utf8.ValidString(got) {
+            t.Errorf("%s: truncate = %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
@@ -1,11 +1,11 @@
 package handlers
 
 import (
+    "errors"
     "net/http"
     "time"
     
     "github.com/gin-gonic/gin"
-    "github.com/go-redis/redis/v8"
     
     "github.com/heydeepakch/url-shortner-golang/config"
     "github.com/heydeepakch/url-shortner-golang/models"
@@ -13,7 +13,7 @@ import (
     "github.com/heydeepakch/url-shortner-golang/utils"
 )
 
-func ShortenURL(c *gin.Context) {
+func (s *Server) ShortenURL(c *gin.Context) {
     var req models.ShortenRequest
     
     if err := c.ShouldBindJSON(&req); err != nil {
@@ -35,7 +35,7 @@ func ShortenURL(c *gin.Context) {
             return
         }
         
-        exists, _ := storage.ShortCodeExists(req.CustomCode)
+        exists, _ := s.URLs.ShortCodeExists(req.CustomCode)
         if exists {
             c.JSON(http.StatusConflict, gin.H{
                 "error": "Custom code already in use",
@@ -45,7 +45,7 @@ func ShortenURL(c *gin.Context) {
         
         shortCode = req.CustomCode
     } else {
-        shortCode, err = utils.GenerateShortCode()
+        shortCode, err = utils.GenerateShortCode(s.URLs)
         if err != nil {
             c.JSON(http.StatusInternalServerError, gin.H{
                 "error": "Failed to generate short code",
@@ -60,94 +60,234 @@ func ShortenURL(c *gin.Context) {
         userID = &uid
     }
     
-    var expiresAt *time.Time
+    if req.ExpiresInHrs > 0 && req.ExpiresAt != nil {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "Use either expires_in_hrs or expires_at, not both",
+        })
+        return
+    }
+    
+    expiresAt := req.ExpiresAt
     if req.ExpiresInHrs > 0 {
         expiry := time.Now().Add(time.Duration(req.ExpiresInHrs) * time.Hour)
         expiresAt = &expiry
     }
     
+    if !validSchedule(c, req.StartsAt, expiresAt) {
+        return
+    }
+    
+    if !validTargeting(c, req.Targeting) || !validVariants(c, req.Variants) {
+        return
+    }
+    
     url := &models.URL{
         ShortCode:   shortCode,
         OriginalURL: req.URL,
+        Title:       req.Title,
+        Targeting:   req.Targeting,
         UserID:      userID,
+        Status:      models.URLStatusActive,
+        StartsAt:    req.StartsAt,
         ExpiresAt:   expiresAt,
     }
     
-    if err := storage.CreateURL(url); err != nil {
+    // Country codes are matched as GeoIP reports them, upper case
+    url.GeoTargeting = normalizeGeoTargeting(req.GeoTargeting)
+    url.Variants = req.Variants
+    
+    url.Type = models.URLTypeRedirect
+    if req.Type != "" {
+        url.Type = req.Type
+    }
+    url.DeepLink = req.DeepLink
+    
+    if !validDeepLink(c, url) || !validDestinations(c, url) {
+        return
+    }
+    
+    if req.MaxClicks > 0 {
+        url.MaxClicks = &req.MaxClicks
+    }
+    
+    if req.RedirectStatus != 0 {
+        url.RedirectStatus = &req.RedirectStatus
+    }
+    
+    if req.Password != "" {
+        hash, err := hashLinkPassword(req.Password)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{
+                "error": "Failed to process password",
+            })
+            return
+        }
+        url.PasswordHash = hash
+    }
+    
+    if err := s.URLs.CreateURL(url); err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{
             "error": "Failed to create short URL",
         })
         return
     }
     
-    storage.CacheURL(url)
+    s.Cache.CacheURL(url)
     
     response := models.ShortenResponse{
-        ShortCode:   shortCode,
-        ShortURL:    config.AppConfig.BaseURL + "/" + shortCode,
-        OriginalURL: req.URL,
-        ExpiresAt:   expiresAt,
+        ShortCode:      shortCode,
+        ShortURL:       config.AppConfig.BaseURL + "/" + shortCode,
+        OriginalURL:    req.URL,
+        Title:          url.Title,
+        Targeting:      url.Targeting,
+        GeoTargeting:   url.GeoTargeting,
+        Variants:       url.Variants,
+        Type:           url.Type,
+        DeepLink:       url.DeepLink,
+        StartsAt:       req.StartsAt,
+        ExpiresAt:      expiresAt,
+        MaxClicks:      url.MaxClicks,
+        Protected:      url.PasswordHash != "",
+        RedirectStatus: redirectStatus(url),
     }
     
     c.JSON(http.StatusCreated, response)
 }
 
-func RedirectURL(c *gin.Context) {
-    shortCode := c.Param("code")
+func (s *Server) RedirectURL(c *gin.Context) {
+    shortCode, preview := previewCode(c)
     
-    url, err := storage.GetCachedURL(shortCode)
+    url, ok := s.resolveURL(c, shortCode)
+    if !ok {
+        return
+    }
     
-    if err == redis.Nil || url == nil {
-        url, err = storage.GetURLByShortCode(shortCode)
-        if err != nil {
-            c.JSON(http.StatusNotFound, gin.H{
-                "error": "URL not found or expired",
-            })
-            return
-        }
-        
-        // Update cache
-        storage.CacheURL(url)
+    if preview {
+        s.renderPreview(c, url)
+        return
     }
     
-    // Increment clicks asynchronously
-    go storage.IncrementClicks(shortCode)
-    go storage.IncrementClicksCache(shortCode)
+    status := redirectStatus(url)
     
-    c.Redirect(http.StatusMovedPermanently, url.OriginalURL)
+    if url.PasswordHash != "" && !hasLinkAccess(c, url) {
+        renderPasswordForm(c, url.ShortCode, http.StatusUnauthorized, "")
+        return
+    }
+    
+    // A cached permanent redirect would skip the password check once the
+    // unlock cookie has expired, or replay a click-limited link after its
+    // last click without ever spending one
+    if url.PasswordHash != "" || url.MaxClicks != nil {
+        c.Header("Cache-Control", "no-store")
+        s.followURL(c, url, temporaryStatus(status))
+        return
+    }
+    
+    c.Header("Cache-Control", redirectCacheControl(url, status))
+    s.followURL(c, url, status)
 }
 
-func GetURLStats(c *gin.Context) {
-    shortCode := c.Param("code")
+// resolveURL loads a link, from the cache when possible, and checks it
+// can be followed right now. If it can't, the response explaining why
+// has been written.
+func (s *Server) resolveURL(c *gin.Context, shortCode string) (*models.URL, bool) {
+    url, err := s.Cache.GetCachedURL(shortCode)
+    
+    if err == nil && url != nil {
+        // A cached link may have been created with a future start
+        err = storage.CheckAvailable(url, time.Now())
+    } else {
+        url, err = s.URLs.GetURLByShortCode(shortCode)
+        if err == nil {
+            // Update cache
+            s.Cache.CacheURL(url)
+        }
+    }
     
-    url, err := storage.GetURLByShortCode(shortCode)
     if err != nil {
-        c.JSON(http.StatusNotFound, gin.H{
-            "error": "URL not found",
-        })
+        linkUnavailable(c, err)
+        return nil, false
+    }
+    
+    return url, true
+}
+
+// followURL counts the click and sends the visitor on to the destination
+func (s *Server) followURL(c *gin.Context, url *models.URL, status int) {
+    // Bots and link previews still get redirected but are counted
+    // apart from human clicks
+    bot := utils.IsBotRequest(c.Request)
+    
+    if url.MaxClicks != nil && !s.spendClick(c, url.ShortCode, bot) {
         return
     }
     
-    if url.UserID != nil {
-        if userID, exists := c.Get("user_id"); exists {
-            if *url.UserID != userID.(int) {
-                c.JSON(http.StatusForbidden, gin.H{
-                    "error": "You don't have permission to view these stats",
-                })
-                return
-            }
+    // Clicks are buffered and written to the database in batches;
+    // the cached record itself is never rewritten on a click
+    destination, variant := s.targetURL(c, url)
+    
+    s.Clicks.Add(url.ShortCode, bot)
+    s.recordClickEvent(c, url.ShortCode, bot, variant)
+    
+    // Deep links try the app on mobile; bots and other platforms get the
+    // web destination
+    if target := deepLinkTarget(c, url); target != nil && !bot {
+        renderBouncePage(c, target, destination)
+        return
+    }
+    
+    c.Redirect(status, destination)
+}
+
+// spendClick takes one redirect from a click-limited link's budget,
+// writing the error response if it can't. Automated requests are refused
+// without spending anything, so a chat unfurl can't burn a one-time link.
+func (s *Server) spendClick(c *gin.Context, shortCode string, bot bool) bool {
+    if bot {
+        linkErrorResponse(c, http.StatusForbidden, "Click-limited links are not served to automated clients", nil)
+        return false
+    }
+    
+    ok, err := s.URLs.ConsumeRedirect(shortCode)
+    if err != nil {
+        linkErrorResponse(c, http.StatusInternalServerError, "Failed to resolve URL", nil)
+        return false
+    }
+    
+    if !ok {
+        linkErrorResponse(c, http.StatusGone, "This link has reached its click limit", nil)
+        return false
+    }
+    
+    return true
+}
+
+func (s *Server) GetURLStats(c *gin.Context) {
+    // The link is loaded inside Read so a concurrent flush can't count
+    // its clicks both as persisted and as pending
+    var url *models.URL
+    var response models.URLStatsResponse
+    ok := false
+    s.Clicks.Read(func() {
+        if url, ok = s.loadStatsURL(c); ok {
+            response = s.statsResponse(url)
         }
+    })
+    if !ok {
+        return
     }
     
-    response := models.URLStatsResponse{
-        URL:      *url,
-        ShortURL: config.AppConfig.BaseURL + "/" + shortCode,
+    // Owned links only get here for their owner. Anyone can read the
+    // stats of an anonymous link, so a password on one must not be
+    // bypassed through them.
+    if url.UserID == nil && response.Protected {
+        hideDestinations(&response.URL)
     }
     
     c.JSON(http.StatusOK, response)
 }
 
-func GetMyURLs(c *gin.Context) {
+func (s *Server) GetMyURLs(c *gin.Context) {
     userID, exists := c.Get("user_id")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{
@@ -156,7 +296,22 @@ func GetMyURLs(c *gin.Context) {
         return
     }
     
-    urls, err := storage.GetUserURLs(userID.(int))
+    // Deleted links are only listed on request, e.g. to restore one
+    includeDeleted := c.Query("include_deleted") == "true"
+    
+    var response []models.URLStatsResponse
+    var err error
+    s.Clicks.Read(func() {
+        var urls []models.URL
+        urls, err = s.URLs.GetUserURLs(userID.(int))
+        for _, url := range urls {
+            if url.DeletedAt != nil && !includeDeleted {
+                continue
+            }
+            
+            response = append(response, s.statsResponse(&url))
+        }
+    })
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{
             "error": "Failed to fetch URLs",
@@ -164,16 +319,374 @@ func GetMyURLs(c *gin.Context) {
         return
     }
     
-    var response []models.URLStatsResponse
-    for _, url := range urls {
-        response = append(response, models.URLStatsResponse{
-            URL:      url,
-            ShortURL: config.AppConfig.BaseURL + "/" + url.ShortCode,
+    c.JSON(http.StatusOK, gin.H{
+        "count": len(response),
+        "urls":  response,
+    })
+}
+
+// UpdateURL changes the destination, link type, device or geo targeting,
+// A/B variants, title, schedule, click limit, password or redirect status
+// of a link owned by the caller
+func (s *Server) UpdateURL(c *gin.Context) {
+    url, ok := s.loadOwnedURL(c)
+    if !ok {
+        return
+    }
+    
+    if url.DeletedAt != nil {
+        c.JSON(http.StatusGone, gin.H{
+            "error": "URL has been deleted, restore it first",
+        })
+        return
+    }
+    
+    var req models.UpdateURLRequest
+    
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "Invalid request data",
+            "details": err.Error(),
+        })
+        return
+    }
+    
+    if req.ExpiresInHrs != nil && req.ExpiresAt != nil {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "Use either expires_in_hrs or expires_at, not both",
         })
+        return
+    }
+    
+    if req.URL != nil {
+        url.OriginalURL = *req.URL
+    }
+    
+    if req.ExpiresInHrs != nil {
+        url.ExpiresAt = nil
+        if *req.ExpiresInHrs > 0 {
+            expiry := time.Now().Add(time.Duration(*req.ExpiresInHrs) * time.Hour)
+            url.ExpiresAt = &expiry
+        }
+    }
+    
+    if req.ExpiresAt != nil {
+        url.ExpiresAt = req.ExpiresAt
     }
     
+    if req.StartsAt != nil {
+        url.StartsAt = req.StartsAt
+    }
+    
+    scheduleChanged := req.ExpiresInHrs != nil || req.ExpiresAt != nil || req.StartsAt != nil
+    if scheduleChanged && !validSchedule(c, url.StartsAt, url.ExpiresAt) {
+        return
+    }
+    
+    if req.MaxClicks != nil {
+        url.MaxClicks = nil
+        if *req.MaxClicks > 0 {
+            url.MaxClicks = req.MaxClicks
+        }
+    }
+    
+    if req.RedirectStatus != nil {
+        url.RedirectStatus = nil
+        if *req.RedirectStatus != 0 {
+            url.RedirectStatus = req.RedirectStatus
+        }
+    }
+    
+    if req.Title != nil {
+        url.Title = *req.Title
+    }
+    
+    if req.Targeting != nil {
+        if !validTargeting(c, *req.Targeting) {
+            return
+        }
+        url.Targeting = *req.Targeting
+    }
+    
+    if req.GeoTargeting != nil {
+        url.GeoTargeting = normalizeGeoTargeting(*req.GeoTargeting)
+    }
+    
+    if req.Variants != nil {
+        if !validVariants(c, *req.Variants) {
+            return
+        }
+        url.Variants = *req.Variants
+    }
+    
+    if req.Type != nil {
+        url.Type = *req.Type
+        if url.Type == models.URLTypeRedirect {
+            url.DeepLink = nil
+        }
+    }
+    
+    if req.DeepLink != nil {
+        url.DeepLink = req.DeepLink
+    }
+    
+    if (req.Type != nil || req.DeepLink != nil) && !validDeepLink(c, url) {
+        return
+    }
+    
+    if req.Password != nil {
+        if *req.Password != "" && len(*req.Password) < 6 {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "error": "Password must be at least 6 characters",
+            })
+            return
+        }
+        
+        url.PasswordHash = ""
+        if *req.Password != "" {
+            hash, err := hashLinkPassword(*req.Password)
+            if err != nil {
+                c.JSON(http.StatusInternalServerError, gin.H{
+                    "error": "Failed to process password",
+                })
+                return
+            }
+            url.PasswordHash = hash
+        }
+    }
+    
+    if !validDestinations(c, url) {
+        return
+    }
+    
+    // The update only applies to the version loaded above, so an edit
+    // made meanwhile, e.g. a pause, is never silently undone
+    err := s.URLs.UpdateURL(url)
+    switch {
+    case errors.Is(err, storage.ErrConflict):
+        c.JSON(http.StatusConflict, gin.H{
+            "error": "URL was modified concurrently, please retry",
+        })
+        return
+    case err != nil:
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "error": "Failed to update URL",
+        })
+        return
+    }
+    
+    // Drop the cached copy so redirects pick up the change immediately
+    s.Cache.DeleteCachedURL(url.ShortCode)
+    
+    c.JSON(http.StatusOK, s.statsResponse(url))
+}
+
+// DeleteURL soft-deletes a link owned by the caller. The code stays
+// reserved and the link can be restored within the retention window.
+func (s *Server) DeleteURL(c *gin.Context) {
+    url, ok := s.loadOwnedURL(c)
+    if !ok {
+        return
+    }
+    
+    // Deleting twice keeps the original deletion time
+    if url.DeletedAt == nil {
+        now := time.Now()
+        if err := s.URLs.DeleteURL(url.ShortCode, now); err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{
+                "error": "Failed to delete URL",
+            })
+            return
+        }
+        url.DeletedAt = &now
+    }
+    
+    s.Cache.DeleteCachedURL(url.ShortCode)
+    
     c.JSON(http.StatusOK, gin.H{
-        "count": len(response),
-        "urls":  response,
+        "message":       "URL deleted",
+        "short_code":    url.ShortCode,
+        "deleted_at":    url.DeletedAt,
+        "restore_until": restoreDeadline(*url.DeletedAt),
     })
 }
+
+// RestoreURL undoes a deletion made within the retention window
+func (s *Server) RestoreURL(c *gin.Context) {
+    url, ok := s.loadOwnedURL(c)
+    if !ok {
+        return
+    }
+    
+    if url.DeletedAt == nil {
+        c.JSON(http.StatusConflict, gin.H{
+            "error": "URL is not deleted",
+        })
+        return
+    }
+    
+    if time.Now().After(restoreDeadline(*url.DeletedAt)) {
+        c.JSON(http.StatusGone, gin.H{
+            "error": "The restore window for this URL has passed",
+        })
+        return
+    }
+    
+    if err := s.URLs.RestoreURL(url.ShortCode); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "error": "Failed to restore URL",
+        })
+        return
+    }
+    url.DeletedAt = nil
+    
+    s.Cache.DeleteCachedURL(url.ShortCode)
+    
+    c.JSON(http.StatusOK, s.statsResponse(url))
+}
+
+// PauseURL stops a link redirecting until it is resumed
+func (s *Server) PauseURL(c *gin.Context) {
+    s.setURLStatus(c, models.URLStatusPaused)
+}
+
+// ResumeURL re-enables a paused link
+func (s *Server) ResumeURL(c *gin.Context) {
+    s.setURLStatus(c, models.URLStatusActive)
+}
+
+// maxStatusAttempts bounds how often a pause or resume is re-applied when
+// the link keeps changing underneath it
+const maxStatusAttempts = 3
+
+func (s *Server) setURLStatus(c *gin.Context, status string) {
+    url, ok := s.loadOwnedURL(c)
+    if !ok {
+        return
+    }
+    
+    if url.DeletedAt != nil {
+        c.JSON(http.StatusGone, gin.H{
+            "error": "URL has been deleted, restore it first",
+        })
+        return
+    }
+    
+    if url.Status != status {
+        // Only the status is changed, so an edit saved meanwhile is kept
+        // by reloading the link and applying the status again
+        url.Status = status
+        err := s.URLs.UpdateURL(url)
+        for attempt := 1; errors.Is(err, storage.ErrConflict) && attempt < maxStatusAttempts; attempt++ {
+            if url, err = s.URLs.GetURL(url.ShortCode); err == nil {
+                url.Status = status
+                err = s.URLs.UpdateURL(url)
+            }
+        }
+        switch {
+        case errors.Is(err, storage.ErrConflict):
+            c.JSON(http.StatusConflict, gin.H{
+                "error": "URL was modified concurrently, please retry",
+            })
+            return
+        case err != nil:
+            c.JSON(http.StatusInternalServerError, gin.H{
+                "error": "Failed to update URL",
+            })
+            return
+        }
+        
+        // Every instance reads the shared cache first, so evicting it is
+        // what makes the new state take effect immediately
+        s.Cache.DeleteCachedURL(url.ShortCode)
+    }
+    
+    c.JSON(http.StatusOK, s.statsResponse(url))
+}
+
+// validSchedule checks an activation window, writing the error response
+// if the expiry is already past or comes before the start
+func validSchedule(c *gin.Context, startsAt, expiresAt *time.Time) bool {
+    if expiresAt == nil {
+        return true
+    }
+    
+    if !expiresAt.After(time.Now()) {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "expires_at must be in the future",
+        })
+        return false
+    }
+    
+    if startsAt != nil && !startsAt.Before(*expiresAt) {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "starts_at must be before expires_at",
+        })
+        return false
+    }
+    
+    return true
+}
+
+// statsResponse adds the clicks still buffered and the remaining click
+// budget to a link loaded from the store, inside Clicks.Read for exact
+// totals
+func (s *Server) statsResponse(url *models.URL) models.URLStatsResponse {
+    pending := s.Clicks.Pending(url.ShortCode)
+    url.Clicks += pending.Human
+    url.BotClicks += pending.Bot
+    
+    response := models.URLStatsResponse{
+        URL:       *url,
+        ShortURL:  config.AppConfig.BaseURL + "/" + url.ShortCode,
+        Protected: url.PasswordHash != "",
+    }
+    
+    if url.MaxClicks != nil {
+        remaining := *url.MaxClicks - url.RedirectCount
+        if remaining < 0 {
+            remaining = 0
+        }
+        response.RemainingClicks = &remaining
+    }
+    
+    return response
+}
+
+// hideDestinations clears every place a link sends visitors, for
+// responses to callers who haven't unlocked it
+func hideDestinations(url *models.URL) {
+    url.OriginalURL = ""
+    url.Targeting = nil
+    url.GeoTargeting = nil
+    url.Variants = nil
+    url.DeepLink = nil
+}
+
+// restoreDeadline is the last moment a link deleted at deletedAt can be restored
+func restoreDeadline(deletedAt time.Time) time.Time {
+    return deletedAt.AddDate(0, 0, config.AppConfig.DeleteRetentionDays)
+}
+
+// loadOwnedURL fetches the link named in the route whatever its state and
+// checks it belongs to the logged in caller, writing the error response
+// if not. Anonymous links have no owner and cannot be managed.
+func (s *Server) loadOwnedURL(c *gin.Context) (*models.URL, bool) {
+    url, err := s.URLs.GetURL(c.Param("code"))
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{
+            "error": "URL not found",
+        })
+        return nil, false
+    }
+    
+    userID, _ := c.Get("user_id")
+    if url.UserID == nil || *url.UserID != userID.(int) {
+        c.JSON(http.StatusForbidden, gin.H{
+            "error": "You don't have permission to modify this URL",
+        })
+        return nil, false
+    }
+    
+    return url, true
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    
+    "github.com/gin-gonic/gin"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+    "github.com/heydeepakch/url-shortner-golang/storage"
+)
+
+// racingStore saves a concurrent edit just before the first UpdateURL
+type racingStore struct {
+    storage.URLStore
+    raced bool
+}
+
+func (s *racingStore) UpdateURL(url *models.URL) error {
+    if !s.raced {
+        s.raced = true
+        other, err := s.URLStore.GetURL(url.ShortCode)
+        if err != nil {
+            return err
+        }
+        other.Title = "Edited meanwhile"
+        if err := s.URLStore.UpdateURL(other); err != nil {
+            return err
+        }
+    }
+    return s.URLStore.UpdateURL(url)
+}
+
+func TestPauseKeepsConcurrentEdit(t *testing.T) {
+    server, router := newTestServer(t)
+    server.URLs = &racingStore{URLStore: server.URLs}
+    router.POST("/api/url/:code/pause", func(c *gin.Context) {
+        c.Set("user_id", 1)
+        server.PauseURL(c)
+    })
+    
+    owner := 1
+    url := &models.URL{ShortCode: "busy", OriginalURL: "https://example.com", UserID: &owner, Status: models.URLStatusActive}
+    if err := server.URLs.CreateURL(url); err != nil {
+        t.Fatal(err)
+    }
+    
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/url/busy/pause", nil))
+    if w.Code != http.StatusOK {
+        t.Fatalf("pause status = %d: %s", w.Code, w.Body)
+    }
+    
+    stored, _ := server.URLs.GetURL("busy")
+    if stored.Status != models.URLStatusPaused || stored.Title != "Edited meanwhile" {
+        t.Fatalf("stored link = %s %q, want paused with the concurrent edit kept", stored.Status, stored.Title)
+    }
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+    "fmt"
+    "math/rand/v2"
+    "net/http"
+    "time"
+    
+    "github.com/gin-gonic/gin"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+const (
+    // variantCookieTTL is how long a visitor keeps their A/B variant
+    variantCookieTTL = 30 * 24 * time.Hour
+    
+    maxVariants = 10
+)
+
+// assignVariant returns the variant this visitor was given before, or
+// picks one by weight and remembers it in a cookie. A remembered variant
+// that has since been removed from the link is replaced.
+func assignVariant(c *gin.Context, url *models.URL) models.Variant {
+    if name, err := c.Cookie(variantCookie(url.ShortCode)); err == nil {
+        for _, variant := range url.Variants {
+            if variant.Name == name {
+                return variant
+            }
+        }
+    }
+    
+    total := 0
+    for _, variant := range url.Variants {
+        total += variant.Weight
+    }
+    
+    chosen := url.Variants[len(url.Variants)-1]
+    pick := rand.IntN(total)
+    for _, variant := range url.Variants {
+        if pick < variant.Weight {
+            chosen = variant
+            break
+        }
+        pick -= variant.Weight
+    }
+    
+    c.SetSameSite(http.SameSiteLaxMode)
+    c.SetCookie(
+        variantCookie(url.ShortCode),
+        chosen.Name,
+        int(variantCookieTTL.Seconds()),
+        "/"+url.ShortCode,
+        "",
+        secureCookies(),
+        true,
+    )
+    
+    return chosen
+}
+
+// validVariants checks an A/B split beyond what binding covers, writing
+// the error response if it is unusable. An empty list means no split.
+func validVariants(c *gin.Context, variants []models.Variant) bool {
+    if len(variants) == 0 {
+        return true
+    }
+    
+    if len(variants) < 2 || len(variants) > maxVariants {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": fmt.Sprintf("An A/B split needs between 2 and %d variants", maxVariants),
+        })
+        return false
+    }
+    
+    seen := make(map[string]bool, len(variants))
+    for _, variant := range variants {
+        if seen[variant.Name] {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "error": fmt.Sprintf("Variant name %q is used more than once", variant.Name),
+            })
+            return false
+        }
+        seen[variant.Name] = true
+    }
+    
+    return true
+}
+
+func variantCookie(shortCode string) string {
+    return "link_variant_" + shortCode
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    
+    "github.com/gin-gonic/gin"
+    
+    "github.com/heydeepakch/url-shortner-golang/config"
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+func newTestContext(cookies ...*http.Cookie) (*gin.Context, *httptest.ResponseRecorder) {
+    gin.SetMode(gin.TestMode)
+    config.AppConfig = &config.Config{BaseURL: "https://sho.rt"}
+    
+    w := httptest.NewRecorder()
+    c, _ := gin.CreateTestContext(w)
+    c.Request = httptest.NewRequest(http.MethodGet, "/promo", nil)
+    for _, cookie := range cookies {
+        c.Request.AddCookie(cookie)
+    }
+    return c, w
+}
+
+func splitURL(weights ...int) *models.URL {
+    url := &models.URL{ShortCode: "promo", OriginalURL: "https://example.com"}
+    for i, weight := range weights {
+        name := string(rune('a' + i))
+        url.Variants = append(url.Variants, models.Variant{
+            Name:   name,
+            URL:    "https://example.com/" + name,
+            Weight: weight,
+        })
+    }
+    return url
+}
+
+func TestValidVariants(t *testing.T) {
+    tests := []struct {
+        name     string
+        variants []models.Variant
+        want     bool
+    }{
+        {"none", nil, true},
+        {"two", splitURL(1, 1).Variants, true},
+        {"ten", splitURL(1, 1, 1, 1, 1, 1, 1, 1, 1, 1).Variants, true},
+        {"one", splitURL(1).Variants, false},
+        {"eleven", splitURL(1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1).Variants, false},
+        {"duplicate names", []models.Variant{
+            {Name: "a", URL: "https://example.com/1", Weight: 1},
+            {Name: "a", URL: "https://example.com/2", Weight: 1},
+        }, false},
+    }
+    
+    for _, tt := range tests {
+        c, w := newTestContext()
+        if got := validVariants(c, tt.variants); got != tt.want {
+            t.Errorf("%s: validVariants = %v, want %v", tt.name, got, tt.want)
+        }
+        if !tt.want && w.Code != http.StatusBadRequest {
+            t.Errorf("%s: status = %d, want 400", tt.name, w.Code)
+        }
+    }
+}
+
+func TestAssignVariantSetsCookie(t *testing.T) {
+    c, w := newTestContext()
+    url := splitURL(1, 1)
+    
+    chosen := assignVariant(c, url)
+    
+    cookie := w.Header().Get("Set-Cookie")
+    if !strings.HasPrefix(cookie, variantCookie("promo")+"="+chosen.Name+";") {
+        t.Fatalf("Set-Cookie = %q, want variant %q", cookie, chosen.Name)
+    }
+    if !strings.Contains(cookie, "Path=/promo") || !strings.Contains(cookie, "Secure") {
+        t.Errorf("Set-Cookie = %q, want Path=/promo and Secure", cookie)
+    }
+}
+
+func TestAssignVariantRemembersVisitor(t *testing.T) {
+    url := splitURL(1, 1000)
+    
+    for i := 0; i < 20; i++ {
+        c, w := newTestContext(&http.Cookie{Name: variantCookie("promo"), Value: "a"})
+        if chosen := assignVariant(c, url); chosen.Name != "a" {
+            t.Fatalf("assignVariant = %q, want remembered variant a", chosen.Name)
+        }
+        if cookie := w.Header().Get("Set-Cookie"); cookie != "" {
+            t.Fatalf("remembered visitor got a new cookie %q", cookie)
+        }
+    }
+}
+
+func TestAssignVariantReplacesRemovedVariant(t *testing.T) {
+    c, w := newTestContext(&http.Cookie{Name: variantCookie("promo"), Value: "gone"})
+    
+    chosen := assignVariant(c, splitURL(1, 1))
+    if chosen.Name != "a" && chosen.Name != "b" {
+        t.Fatalf("assignVariant = %q, want a or b", chosen.Name)
+    }
+    if w.Header().Get("Set-Cookie") == "" {
+        t.Fatal("stale variant cookie was not replaced")
+    }
+}
+
+func TestAssignVariantFollowsWeights(t *testing.T) {
+    url := splitURL(1, 999)
+    
+    heavy := 0
+    for i := 0; i < 1000; i++ {
+        c, _ := newTestContext()
+        if assignVariant(c, url).Name == "b" {
+            heavy++
+        }
+    }
+    
+    // Expected 999 of 1000; the bound leaves room for chance
+    if heavy < 950 {
+        t.Fatalf("weight 999 variant chosen %d of 1000 times", heavy)
+    }
+}
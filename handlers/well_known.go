@@ -0,0 +1,70 @@
+package handlers
+
+import (
+    "net/http"
+    
+    "github.com/gin-gonic/gin"
+    
+    "github.com/heydeepakch/url-shortner-golang/config"
+)
+
+// AppleAppSiteAssociation lets the configured iOS apps open the
+// configured paths on this domain as universal links. Only those paths
+// are claimed, so ordinary short links keep redirecting in the browser
+// with the app installed.
+func (s *Server) AppleAppSiteAssociation(c *gin.Context) {
+    appIDs := config.AppConfig.IOSAppIDs
+    paths := config.AppConfig.IOSAppPaths
+    if len(appIDs) == 0 || len(paths) == 0 {
+        c.JSON(http.StatusNotFound, gin.H{
+            "error": "No iOS apps configured",
+        })
+        return
+    }
+    
+    components := make([]gin.H, len(paths))
+    for i, path := range paths {
+        components[i] = gin.H{"/": path}
+    }
+    
+    // iOS 13 and later read appIDs and components; iOS 12 and earlier
+    // read appID and paths, and need "apps" present and empty
+    details := make([]gin.H, len(appIDs))
+    for i, appID := range appIDs {
+        details[i] = gin.H{
+            "appIDs":     []string{appID},
+            "components": components,
+            "appID":      appID,
+            "paths":      paths,
+        }
+    }
+    
+    c.JSON(http.StatusOK, gin.H{
+        "applinks": gin.H{
+            "apps":    []string{},
+            "details": details,
+        },
+    })
+}
+
+// AssetLinks lets the configured Android app open short links on this
+// domain as verified app links
+func (s *Server) AssetLinks(c *gin.Context) {
+    pkg := config.AppConfig.AndroidAppPackage
+    fingerprints := config.AppConfig.AndroidCertFingerprints
+    if pkg == "" || len(fingerprints) == 0 {
+        c.JSON(http.StatusNotFound, gin.H{
+            "error": "No Android app configured",
+        })
+        return
+    }
+    
+    c.JSON(http.StatusOK, []gin.H{{
+        "relation": []string{"delegate_permission/common.handle_all_urls"},
+        "target": gin.H{
+            "namespace":                "android_app",
+            "package_name":             pkg,
+            "sha256_cert_fingerprints": fingerprints,
+        },
+    }})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "testing"
+    
+    "github.com/heydeepakch/url-shortner-golang/config"
+)
+
+func TestAppleAppSiteAssociation(t *testing.T) {
+    c, w := newTestContext()
+    server := &Server{}
+    
+    server.AppleAppSiteAssociation(c)
+    if w.Code != http.StatusNotFound {
+        t.Fatalf("unconfigured status = %d, want 404", w.Code)
+    }
+    
+    c, w = newTestContext()
+    config.AppConfig.IOSAppIDs = []string{"TEAMID.com.example.app"}
+    server.AppleAppSiteAssociation(c)
+    if w.Code != http.StatusNotFound {
+        t.Fatalf("status without paths = %d, want 404 rather than claiming every link", w.Code)
+    }
+    
+    c, w = newTestContext()
+    config.AppConfig.IOSAppIDs = []string{"TEAMID.com.example.app"}
+    config.AppConfig.IOSAppPaths = []string{"/app/*"}
+    server.AppleAppSiteAssociation(c)
+    
+    var body struct {
+        Applinks struct {
+            Apps    []string `json:"apps"`
+            Details []struct {
+                AppIDs     []string            `json:"appIDs"`
+                Components []map[string]string `json:"components"`
+                AppID      string              `json:"appID"`
+                Paths      []string            `json:"paths"`
+            } `json:"details"`
+        } `json:"applinks"`
+    }
+    if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+        t.Fatal(err)
+    }
+    
+    if body.Applinks.Apps == nil || len(body.Applinks.Apps) != 0 {
+        t.Errorf("apps = %v, want present and empty", body.Applinks.Apps)
+    }
+    if len(body.Applinks.Details) != 1 {
+        t.Fatalf("got %d details, want 1", len(body.Applinks.Details))
+    }
+    detail := body.Applinks.Details[0]
+    if detail.AppID != "TEAMID.com.example.app" || len(detail.AppIDs) != 1 || detail.AppIDs[0] != detail.AppID {
+        t.Errorf("app IDs = %q / %v", detail.AppID, detail.AppIDs)
+    }
+    if len(detail.Components) != 1 || detail.Components[0]["/"] != "/app/*" {
+        t.Errorf("components = %v, want only /app/*", detail.Components)
+    }
+    if len(detail.Paths) != 1 || detail.Paths[0] != "/app/*" {
+        t.Errorf("paths = %v, want only /app/*", detail.Paths)
+    }
+}
+
+func TestAssetLinks(t *testing.T) {
+    c, w := newTestContext()
+    server := &Server{}
+    
+    server.AssetLinks(c)
+    if w.Code != http.StatusNotFound {
+        t.Fatalf("unconfigured status = %d, want 404", w.Code)
+    }
+    
+    c, w = newTestContext()
+    config.AppConfig.AndroidAppPackage = "com.example.app"
+    config.AppConfig.AndroidCertFingerprints = []string{"AB:CD"}
+    server.AssetLinks(c)
+    
+    var body []struct {
+        Relation []string `json:"relation"`
+        Target   struct {
+            Namespace    string   `json:"namespace"`
+            PackageName  string   `json:"package_name"`
+            Fingerprints []string `json:"sha256_cert_fingerprints"`
+        } `json:"target"`
+    }
+    if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+        t.Fatal(err)
+    }
+    if len(body) != 1 || body[0].Target.PackageName != "com.example.app" || body[0].Target.Fingerprints[0] != "AB:CD" {
+        t.Fatalf("assetlinks = %+v", body)
+    }
+}
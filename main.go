@@ -1,63 +1,179 @@
 package main
 
 import (
+    "context"
     "log"
+    "net/http"
     "os"
     "os/signal"
     "syscall"
+    "time"
+    _ "time/tzdata"
     
     "github.com/gin-gonic/gin"
     
     "github.com/heydeepakch/url-shortner-golang/config"
     "github.com/heydeepakch/url-shortner-golang/database"
+    "github.com/heydeepakch/url-shortner-golang/geoip"
     "github.com/heydeepakch/url-shortner-golang/handlers"
     "github.com/heydeepakch/url-shortner-golang/middleware"
+    "github.com/heydeepakch/url-shortner-golang/storage"
 )
 
 func main() {
 
+    if len(os.Args) > 1 && os.Args[1] == "migrate" {
+        runMigrate(os.Args[2:])
+        return
+    }
+    
     config.LoadConfig()
     
-    if err := database.InitPostgres(config.AppConfig.DatabaseURL); err != nil {
-        log.Fatal("Failed to connect to PostgreSQL:", err)
+    var urls storage.URLStore
+    var users storage.UserStore
+    var clickStore storage.ClickStore
+    
+    switch config.AppConfig.DatabaseDriver {
+    case "memory":
+        // Everything lives in process memory; no Postgres needed
+        store := storage.NewMemoryStore()
+        urls, users, clickStore = store, store, store
+        log.Println("Using in-memory storage")
+    case "postgres":
+        if err := database.InitPostgres(config.AppConfig.DatabaseURL); err != nil {
+            log.Fatal("Failed to connect to PostgreSQL:", err)
+        }
+        defer database.CloseDB()
+        
+        store := storage.NewPostgresStore(database.DB)
+        urls, users, clickStore = store, store, store
+    case "sqlite":
+        // Single data file, no Postgres needed
+        if err := database.InitSQLite(config.AppConfig.SQLitePath); err != nil {
+            log.Fatal("Failed to open SQLite database:", err)
+        }
+        defer database.CloseDB()
+        
+        store := storage.NewSQLiteStore(database.DB)
+        urls, users, clickStore = store, store, store
+    default:
+        log.Fatalf("Unknown DATABASE_DRIVER %q", config.AppConfig.DatabaseDriver)
     }
-    defer database.CloseDB()
-    
-    if err := database.InitRedis(
-        config.AppConfig.RedisAddr,
-        config.AppConfig.RedisPassword,
-        config.AppConfig.RedisDB,
-    ); err != nil {
-        log.Fatal("Failed to connect to Redis:", err)
+    
+    localCache := storage.NewMemoryCache(config.AppConfig.CacheMaxItems)
+    
+    var cache storage.Cache
+    
+    switch config.AppConfig.CacheDriver {
+    case "memory":
+        cache = localCache
+        log.Println("Using in-process cache")
+    case "redis":
+        // Redis is only a cache, so keep serving without it; the client
+        // reconnects on its own and the fallback covers the gap
+        if err := database.InitRedis(
+            config.AppConfig.RedisAddr,
+            config.AppConfig.RedisPassword,
+            config.AppConfig.RedisDB,
+        ); err != nil {
+            log.Println("Failed to connect to Redis, using in-process cache until it recovers:", err)
+        }
+        defer database.CloseRedis()
+        
+        cache = storage.NewFallbackCache(storage.NewRedisCache(database.RedisClient), localCache)
+    default:
+        log.Fatalf("Unknown CACHE_DRIVER %q", config.AppConfig.CacheDriver)
     }
-    defer database.CloseRedis()
+    
+    urlCache := storage.NewURLCache(cache)
+    
+    clicks := storage.NewClickBuffer(urls, urlCache)
+    clicks.Start(time.Duration(config.AppConfig.ClickFlushSeconds) * time.Second)
+    
+    events := storage.NewClickEventWriter(
+        clickStore,
+        config.AppConfig.ClickEventBuffer,
+        config.AppConfig.ClickEventBatch,
+        time.Duration(config.AppConfig.ClickFlushSeconds) * time.Second,
+    )
+    events.Start()
+    
+    // Expired links are archived (or purged, keeping their codes reserved)
+    // once the grace period has passed; "keep" leaves them in place
+    switch config.AppConfig.ExpiredURLAction {
+    case "archive", "purge":
+        reaper := storage.NewURLReaper(
+            urls,
+            time.Duration(config.AppConfig.ExpiredGraceHours) * time.Hour,
+            config.AppConfig.ExpiredURLAction == "archive",
+        )
+        reaper.Start(time.Duration(config.AppConfig.ReaperIntervalMinutes) * time.Minute)
+        defer reaper.Stop()
+    case "keep":
+    default:
+        log.Fatalf("Unknown EXPIRED_URL_ACTION %q", config.AppConfig.ExpiredURLAction)
+    }
+    
+    geo, err := geoip.Open(config.AppConfig.GeoIPPath, config.AppConfig.GeoIPCity)
+    if err != nil {
+        log.Fatal("Failed to open GeoIP database:", err)
+    }
+    defer geo.Close()
+    
+    server := handlers.NewServer(urls, users, urlCache, clicks, events, clickStore, geo)
     
     router := gin.Default()
     
+    // Client IPs feed analytics, rate limits and geo targeting, so
+    // forwarding headers are only believed from known proxies
+    if err := router.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+        log.Fatal("Invalid TRUSTED_PROXIES:", err)
+    }
+    router.RemoteIPHeaders = config.AppConfig.ClientIPHeaders
+    
     router.Use(corsMiddleware())
     
     router.GET("/health", func(c *gin.Context) {
         c.JSON(200, gin.H{"status": "healthy"})
     })
     
-    router.POST("/api/register", handlers.Register)
-    router.POST("/api/login", handlers.Login)
+    router.POST("/api/register", server.Register)
+    router.POST("/api/login", server.Login)
 
-    router.POST("/api/shorten", middleware.OptionalAuthMiddleware(), handlers.ShortenURL)
+    router.POST("/api/shorten", middleware.OptionalAuthMiddleware(), server.ShortenURL)
     
-    router.GET("/:code", handlers.RedirectURL)
+    // App association files for universal links and Android app links
+    router.GET("/.well-known/apple-app-site-association", server.AppleAppSiteAssociation)
+    router.GET("/apple-app-site-association", server.AppleAppSiteAssociation)
+    router.GET("/.well-known/assetlinks.json", server.AssetLinks)
     
-    router.GET("/api/url/:code/stats", middleware.OptionalAuthMiddleware(), handlers.GetURLStats)
+    router.GET("/:code", server.RedirectURL)
+    router.HEAD("/:code", server.RedirectURL)
+    router.POST("/:code", server.UnlockURL)
+    
+    router.GET("/api/url/:code/stats", middleware.OptionalAuthMiddleware(), server.GetURLStats)
+    router.GET("/api/url/:code/stats/timeseries", middleware.OptionalAuthMiddleware(), server.GetURLTimeSeries)
+    router.GET("/api/url/:code/stats/breakdown", middleware.OptionalAuthMiddleware(), server.GetURLBreakdown)
     
     protected := router.Group("/api")
     protected.Use(middleware.AuthMiddleware())
     {
-        protected.GET("/profile", handlers.GetProfile)
-        protected.GET("/my-urls", handlers.GetMyURLs)
+        protected.GET("/profile", server.GetProfile)
+        protected.GET("/my-urls", server.GetMyURLs)
+        protected.PATCH("/url/:code", server.UpdateURL)
+        protected.DELETE("/url/:code", server.DeleteURL)
+        protected.POST("/url/:code/restore", server.RestoreURL)
+        protected.POST("/url/:code/pause", server.PauseURL)
+        protected.POST("/url/:code/resume", server.ResumeURL)
+    }
+    
+    srv := &http.Server{
+        Addr:    ":" + config.AppConfig.Port,
+        Handler: router,
     }
     
     go func() {
-        if err := router.Run(":" + config.AppConfig.Port); err != nil {
+        if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
             log.Fatal("Failed to start server:", err)
         }
     }()
@@ -69,13 +185,25 @@ func main() {
     <-quit
     
     log.Println("Shutting down server...")
+    
+    // Let in-flight redirects finish before the final click flush
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    if err := srv.Shutdown(ctx); err != nil {
+        log.Println("Server shutdown error:", err)
+    }
+    
+    if err := clicks.Stop(); err != nil {
+        log.Println("Failed to flush click counts:", err)
+    }
+    events.Stop()
 }
 
 func corsMiddleware() gin.HandlerFunc {
     return func(c *gin.Context) {
         c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-        c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+        c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE")
         c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
         
         if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,90 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "strconv"
+    
+    "github.com/heydeepakch/url-shortner-golang/config"
+    "github.com/heydeepakch/url-shortner-golang/database"
+)
+
+const migrateUsage = `usage: url-shortner migrate <command>
+
+commands:
+  status    list migrations and whether they are applied
+  up        apply all pending migrations
+  down [N]  roll back the last N applied migrations (default 1)`
+
+// runMigrate implements the "migrate" subcommand
+func runMigrate(args []string) {
+    if len(args) == 0 {
+        fmt.Fprintln(os.Stderr, migrateUsage)
+        os.Exit(2)
+    }
+    
+    config.LoadConfig()
+    
+    driver := config.AppConfig.DatabaseDriver
+    switch driver {
+    case "postgres":
+        if err := database.ConnectPostgres(config.AppConfig.DatabaseURL); err != nil {
+            log.Fatal("Failed to connect to PostgreSQL:", err)
+        }
+    case "sqlite":
+        if err := database.ConnectSQLite(config.AppConfig.SQLitePath); err != nil {
+            log.Fatal("Failed to open SQLite database:", err)
+        }
+    default:
+        log.Fatalf("DATABASE_DRIVER %q has no migrations", driver)
+    }
+    defer database.CloseDB()
+    
+    migrator, err := database.NewMigrator(database.DB, driver)
+    if err != nil {
+        log.Fatal("Failed to load migrations:", err)
+    }
+    
+    switch args[0] {
+    case "status":
+        statuses, err := migrator.Status()
+        if err != nil {
+            log.Fatal("Failed to read migration status:", err)
+        }
+        
+        for _, status := range statuses {
+            applied := "pending"
+            if status.AppliedAt != nil {
+                applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+            }
+            fmt.Printf("%04d  %-40s %s\n", status.Version, status.Name, applied)
+        }
+        
+    case "up":
+        count, err := migrator.Up()
+        if err != nil {
+            log.Fatal("Migration failed:", err)
+        }
+        fmt.Printf("Applied %d migration(s)\n", count)
+        
+    case "down":
+        n := 1
+        if len(args) > 1 {
+            n, err = strconv.Atoi(args[1])
+            if err != nil || n < 1 {
+                log.Fatalf("Invalid migration count %q", args[1])
+            }
+        }
+        
+        count, err := migrator.Down(n)
+        if err != nil {
+            log.Fatal("Rollback failed:", err)
+        }
+        fmt.Printf("Rolled back %d migration(s)\n", count)
+        
+    default:
+        fmt.Fprintln(os.Stderr, migrateUsage)
+        os.Exit(2)
+    }
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// ClickEvent is a single redirect, recorded for analytics
+type ClickEvent struct {
+    ID             int64     `json:"id"`
+    ShortCode      string    `json:"short_code"`
+    ClickedAt      time.Time `json:"clicked_at"`
+    Referrer       string    `json:"referrer,omitempty"`
+    UserAgent      string    `json:"user_agent,omitempty"`
+    IPHash         string    `json:"ip_hash,omitempty"`
+    AcceptLanguage string    `json:"accept_language,omitempty"`
+    IsBot          bool      `json:"is_bot"`
+    
+    // Derived at redirect time for breakdowns
+    ReferrerDomain string `json:"referrer_domain,omitempty"`
+    Browser        string `json:"browser,omitempty"`
+    OS             string `json:"os,omitempty"`
+    Device         string `json:"device,omitempty"`
+    
+    // Resolved from the client IP when a GeoIP database is configured
+    Country string `json:"country,omitempty"`
+    Region  string `json:"region,omitempty"`
+    City    string `json:"city,omitempty"`
+    
+    // Variant is the A/B destination the visitor was sent to, if any
+    Variant string `json:"variant,omitempty"`
+}
+
+// ClickBucket is the number of clicks in one time bucket
+type ClickBucket struct {
+    Start  time.Time `json:"start"`
+    Clicks int64     `json:"clicks"`
+}
+
+type TimeSeriesResponse struct {
+    ShortCode   string        `json:"short_code"`
+    Granularity string        `json:"granularity"`
+    Timezone    string        `json:"timezone"`
+    From        time.Time     `json:"from"`
+    To          time.Time     `json:"to"`
+    IncludeBots bool          `json:"include_bots"`
+    Total       int64         `json:"total"`
+    Buckets     []ClickBucket `json:"buckets"`
+}
+
+// BreakdownEntry is the click count for one value of a dimension
+type BreakdownEntry struct {
+    Value  string `json:"value"`
+    Clicks int64  `json:"clicks"`
+}
+
+type BreakdownResponse struct {
+    ShortCode   string           `json:"short_code"`
+    From        *time.Time       `json:"from,omitempty"`
+    To          time.Time        `json:"to"`
+    IncludeBots bool             `json:"include_bots"`
+    Referrers   []BreakdownEntry `json:"referrers"`
+    Browsers    []BreakdownEntry `json:"browsers"`
+    OS          []BreakdownEntry `json:"os"`
+    Devices     []BreakdownEntry `json:"devices"`
+    Countries   []BreakdownEntry `json:"countries"`
+    Regions     []BreakdownEntry `json:"regions"`
+    Cities      []BreakdownEntry `json:"cities"`
+    Variants    []BreakdownEntry `json:"variants"`
+}
@@ -2,30 +2,175 @@ package models
 
 import "time"
 
+// Link states. Paused links keep their code but don't redirect.
+const (
+    URLStatusActive = "active"
+    URLStatusPaused = "paused"
+)
+
+// Link types. Deep links open an app through a bounce page, falling back
+// to the store or the web destination.
+const (
+    URLTypeRedirect = "redirect"
+    URLTypeDeepLink = "deeplink"
+)
+
 type URL struct {
     ID          int        `json:"id"`
     ShortCode   string     `json:"short_code"`
-    OriginalURL string     `json:"original_url"`
+    OriginalURL string     `json:"original_url,omitempty"`
+    Title       string     `json:"title,omitempty"`
     UserID      *int       `json:"user_id,omitempty"` 
+    Status      string     `json:"status"`
     Clicks      int64      `json:"clicks"`
+    BotClicks   int64      `json:"bot_clicks"`
+    MaxClicks   *int64     `json:"max_clicks,omitempty"`
+    
+    // RedirectStatus is the HTTP status used to redirect, nil for the
+    // server default
+    RedirectStatus *int `json:"redirect_status,omitempty"`
+    
+    // Targeting sends matching visitors somewhere other than
+    // OriginalURL. The first matching rule wins.
+    Targeting []TargetingRule `json:"targeting,omitempty"`
+    
+    // GeoTargeting maps ISO 3166-1 alpha-2 country codes to destinations
+    // for visitors from that country. Targeting rules are checked first.
+    GeoTargeting map[string]string `json:"geo_targeting,omitempty"`
+    
+    // Variants split the remaining visitors between destinations by
+    // weight, each visitor sticking to the one they were first given
+    Variants []Variant `json:"variants,omitempty"`
+    
+    // Type is URLTypeRedirect or URLTypeDeepLink; DeepLink holds the app
+    // targets of the latter
+    Type     string    `json:"type"`
+    DeepLink *DeepLink `json:"deep_link,omitempty"`
+    
+    // RedirectCount is the number of redirects spent against MaxClicks
+    RedirectCount int64 `json:"-"`
+    
+    // Version is bumped by every UpdateURL, which only succeeds against
+    // the version it was loaded at
+    Version int64 `json:"-"`
+    
+    // PasswordHash is the bcrypt hash visitors must match, empty if the
+    // link is not password protected
+    PasswordHash string `json:"-"`
+    
     CreatedAt   time.Time  `json:"created_at"`
+    StartsAt    *time.Time `json:"starts_at,omitempty"`
     ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+    DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// TargetingRule redirects visitors whose User-Agent matches every
+// condition given. OS is a family such as "iOS" or "Android"; Device is
+// desktop, mobile, tablet or bot.
+type TargetingRule struct {
+    OS     string `json:"os,omitempty"`
+    Device string `json:"device,omitempty" binding:"omitempty,oneof=desktop mobile tablet bot"`
+    URL    string `json:"url" binding:"required,url"`
+}
+
+// Variant is one destination of an A/B split. Visitors are assigned in
+// proportion to Weight.
+type Variant struct {
+    Name   string `json:"name" binding:"required,max=32"`
+    URL    string `json:"url" binding:"required,url"`
+    Weight int    `json:"weight" binding:"required,min=1,max=1000"`
+}
+
+// DeepLink is where a deeplink link sends visitors on each mobile
+// platform. Other platforms get the link's web destination.
+type DeepLink struct {
+    IOS     *AppTarget `json:"ios,omitempty"`
+    Android *AppTarget `json:"android,omitempty"`
 }
 
+// AppTarget opens an app through a custom scheme or universal/app link,
+// falling back to StoreURL (or the web destination) when the app isn't
+// installed
+type AppTarget struct {
+    AppURL   string `json:"app_url" binding:"required,uri"`
+    StoreURL string `json:"store_url,omitempty" binding:"omitempty,url"`
+}
+
+// ShortenRequest creates a link. Expiry is given either relative
+// (expires_in_hrs) or absolute (expires_at, RFC3339); starts_at delays
+// activation until that instant. A deeplink type needs deep_link.
 type ShortenRequest struct {
-    URL          string `json:"url" binding:"required,url"`
-    CustomCode   string `json:"custom_code,omitempty"`
-    ExpiresInHrs int    `json:"expires_in_hrs,omitempty"`
+    URL            string            `json:"url" binding:"required,url"`
+    CustomCode     string            `json:"custom_code,omitempty"`
+    ExpiresInHrs   int               `json:"expires_in_hrs,omitempty"`
+    ExpiresAt      *time.Time        `json:"expires_at,omitempty"`
+    StartsAt       *time.Time        `json:"starts_at,omitempty"`
+    MaxClicks      int64             `json:"max_clicks,omitempty" binding:"omitempty,min=1"`
+    Password       string            `json:"password,omitempty" binding:"omitempty,min=6"`
+    RedirectStatus int               `json:"redirect_status,omitempty" binding:"omitempty,oneof=301 302 307 308"`
+    Title          string            `json:"title,omitempty" binding:"omitempty,max=200"`
+    Targeting      []TargetingRule   `json:"targeting,omitempty" binding:"omitempty,max=20,dive"`
+    GeoTargeting   map[string]string `json:"geo_targeting,omitempty" binding:"omitempty,max=250,dive,keys,len=2,alpha,endkeys,url"`
+    Variants       []Variant         `json:"variants,omitempty" binding:"omitempty,dive"`
+    Type           string            `json:"type,omitempty" binding:"omitempty,oneof=redirect deeplink"`
+    DeepLink       *DeepLink         `json:"deep_link,omitempty"`
+}
+
+// UpdateURLRequest changes a link in place. Omitted fields are left as
+// they are; expires_in_hrs 0 removes the expiry, max_clicks 0 the click
+// limit, a starts_at in the past activates the link immediately, an
+// empty password removes the password, redirect_status 0 goes back to
+// the server default, an empty title removes the title, and an empty
+// targeting list, geo_targeting map or variants list sends everyone to
+// url. Changing type to redirect drops deep_link.
+type UpdateURLRequest struct {
+    URL            *string            `json:"url,omitempty" binding:"omitempty,url"`
+    ExpiresInHrs   *int               `json:"expires_in_hrs,omitempty" binding:"omitempty,min=0"`
+    ExpiresAt      *time.Time         `json:"expires_at,omitempty"`
+    StartsAt       *time.Time         `json:"starts_at,omitempty"`
+    MaxClicks      *int64             `json:"max_clicks,omitempty" binding:"omitempty,min=0"`
+    Password       *string            `json:"password,omitempty"`
+    RedirectStatus *int               `json:"redirect_status,omitempty" binding:"omitempty,oneof=0 301 302 307 308"`
+    Title          *string            `json:"title,omitempty" binding:"omitempty,max=200"`
+    Targeting      *[]TargetingRule   `json:"targeting,omitempty" binding:"omitempty,max=20,dive"`
+    GeoTargeting   *map[string]string `json:"geo_targeting,omitempty" binding:"omitempty,max=250,dive,keys,len=2,alpha,endkeys,url"`
+    Variants       *[]Variant         `json:"variants,omitempty" binding:"omitempty,dive"`
+    Type           *string            `json:"type,omitempty" binding:"omitempty,oneof=redirect deeplink"`
+    DeepLink       *DeepLink          `json:"deep_link,omitempty"`
 }
 
 type ShortenResponse struct {
-    ShortCode   string     `json:"short_code"`
-    ShortURL    string     `json:"short_url"`
-    OriginalURL string     `json:"original_url"`
-    ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+    ShortCode      string            `json:"short_code"`
+    ShortURL       string            `json:"short_url"`
+    OriginalURL    string            `json:"original_url"`
+    Title          string            `json:"title,omitempty"`
+    Targeting      []TargetingRule   `json:"targeting,omitempty"`
+    GeoTargeting   map[string]string `json:"geo_targeting,omitempty"`
+    Variants       []Variant         `json:"variants,omitempty"`
+    Type           string            `json:"type"`
+    DeepLink       *DeepLink         `json:"deep_link,omitempty"`
+    StartsAt       *time.Time        `json:"starts_at,omitempty"`
+    ExpiresAt      *time.Time        `json:"expires_at,omitempty"`
+    MaxClicks      *int64            `json:"max_clicks,omitempty"`
+    Protected      bool              `json:"password_protected"`
+    RedirectStatus int               `json:"redirect_status"`
 }
 
 type URLStatsResponse struct {
     URL
-    ShortURL string `json:"short_url"`
+    ShortURL        string `json:"short_url"`
+    RemainingClicks *int64 `json:"remaining_clicks,omitempty"`
+    Protected       bool   `json:"password_protected"`
+}
+
+// LinkPreviewResponse describes a link without following it. The
+// destination is left out of password protected links.
+type LinkPreviewResponse struct {
+    ShortCode   string    `json:"short_code"`
+    ShortURL    string    `json:"short_url"`
+    OriginalURL string    `json:"original_url,omitempty"`
+    Title       string    `json:"title,omitempty"`
+    CreatedAt   time.Time `json:"created_at"`
+    Clicks      int64     `json:"clicks"`
+    Protected   bool      `json:"password_protected"`
 }
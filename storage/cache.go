@@ -0,0 +1,153 @@
+package storage
+
+import (
+    "errors"
+    "log"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// ErrCacheMiss is returned by Cache.Get when the key is not present
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache is a small key/value cache with expiry
+type Cache interface {
+    Get(key string) ([]byte, error)
+    Set(key string, value []byte, ttl time.Duration) error
+    Delete(key string) error
+    // Incr adds delta to the integer stored at key and returns the new value.
+    // ttl is only applied when the key has no expiry yet; zero means none.
+    Incr(key string, delta int64, ttl time.Duration) (int64, error)
+}
+
+const (
+    // fallbackTTL caps how long entries written to the fallback cache
+    // live, since other instances can't invalidate them
+    fallbackTTL = time.Minute
+    
+    // primaryRetryInterval is how long the primary is skipped after an
+    // error, so an unreachable Redis doesn't add its timeouts to every request
+    primaryRetryInterval = 5 * time.Second
+)
+
+// FallbackCache uses primary (Redis) and switches to the in-process
+// fallback whenever primary returns an error, so a Redis outage degrades
+// caching instead of failing requests
+type FallbackCache struct {
+    primary  Cache
+    fallback Cache
+    retryAt  atomic.Int64
+    
+    // pendingDeletes are invalidations the primary missed during an
+    // outage, replayed before it is used again
+    mu             sync.Mutex
+    pendingDeletes map[string]struct{}
+}
+
+func NewFallbackCache(primary, fallback Cache) *FallbackCache {
+    return &FallbackCache{primary: primary, fallback: fallback}
+}
+
+func (c *FallbackCache) Get(key string) ([]byte, error) {
+    if c.primaryUp() && c.flushDeletes() {
+        value, err := c.primary.Get(key)
+        if !c.failed(err) {
+            return value, err
+        }
+    }
+    return c.fallback.Get(key)
+}
+
+func (c *FallbackCache) Set(key string, value []byte, ttl time.Duration) error {
+    if c.primaryUp() && c.flushDeletes() {
+        if err := c.primary.Set(key, value, ttl); !c.failed(err) {
+            return nil
+        }
+    }
+    
+    if ttl == 0 || ttl > fallbackTTL {
+        ttl = fallbackTTL
+    }
+    return c.fallback.Set(key, value, ttl)
+}
+
+func (c *FallbackCache) Delete(key string) error {
+    // Always clear the fallback. A delete the primary can't take now,
+    // because it is marked down or fails, is queued and replayed before
+    // the primary is used again, so the entry can't come back with it.
+    c.fallback.Delete(key)
+    
+    pending := map[string]struct{}{key: {}}
+    if !c.primaryUp() {
+        c.queueDeletes(pending)
+        return nil
+    }
+    
+    if err := c.primary.Delete(key); c.failed(err) {
+        c.queueDeletes(pending)
+        return err
+    }
+    return nil
+}
+
+func (c *FallbackCache) Incr(key string, delta int64, ttl time.Duration) (int64, error) {
+    if c.primaryUp() && c.flushDeletes() {
+        value, err := c.primary.Incr(key, delta, ttl)
+        if !c.failed(err) {
+            return value, err
+        }
+    }
+    return c.fallback.Incr(key, delta, ttl)
+}
+
+// flushDeletes replays queued invalidations, reporting false if the
+// primary is still failing
+func (c *FallbackCache) flushDeletes() bool {
+    c.mu.Lock()
+    keys := c.pendingDeletes
+    c.pendingDeletes = nil
+    c.mu.Unlock()
+    
+    for key := range keys {
+        if err := c.primary.Delete(key); c.failed(err) {
+            c.queueDeletes(keys)
+            return false
+        }
+        delete(keys, key)
+    }
+    return true
+}
+
+func (c *FallbackCache) queueDeletes(keys map[string]struct{}) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    
+    if c.pendingDeletes == nil {
+        c.pendingDeletes = make(map[string]struct{}, len(keys))
+    }
+    for key := range keys {
+        c.pendingDeletes[key] = struct{}{}
+    }
+}
+
+func (c *FallbackCache) primaryUp() bool {
+    return time.Now().UnixNano() >= c.retryAt.Load()
+}
+
+// failed reports whether err is a primary outage rather than a normal
+// miss, logging when the cache degrades or recovers
+func (c *FallbackCache) failed(err error) bool {
+    if err == nil || errors.Is(err, ErrCacheMiss) {
+        if c.retryAt.Swap(0) != 0 {
+            log.Println("Primary cache recovered")
+        }
+        return false
+    }
+    
+    retryAt := time.Now().Add(primaryRetryInterval).UnixNano()
+    if c.retryAt.Swap(retryAt) == 0 {
+        log.Println("Primary cache unavailable, using in-process cache:", err)
+    }
+    return true
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+    "errors"
+    "sync"
+    "testing"
+    "time"
+)
+
+// flakyCache wraps a MemoryCache and fails every call while down,
+// counting the calls it receives
+type flakyCache struct {
+    *MemoryCache
+    mu    sync.Mutex
+    down  bool
+    calls int
+}
+
+func newFlakyCache() *flakyCache {
+    return &flakyCache{MemoryCache: NewMemoryCache(100)}
+}
+
+func (c *flakyCache) check() error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    
+    c.calls++
+    if c.down {
+        return errors.New("connection refused")
+    }
+    return nil
+}
+
+func (c *flakyCache) setDown(down bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.down = down
+}
+
+func (c *flakyCache) callCount() int {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.calls
+}
+
+func (c *flakyCache) Get(key string) ([]byte, error) {
+    if err := c.check(); err != nil {
+        return nil, err
+    }
+    return c.MemoryCache.Get(key)
+}
+
+func (c *flakyCache) Set(key string, value []byte, ttl time.Duration) error {
+    if err := c.check(); err != nil {
+        return err
+    }
+    return c.MemoryCache.Set(key, value, ttl)
+}
+
+func (c *flakyCache) Delete(key string) error {
+    if err := c.check(); err != nil {
+        return err
+    }
+    return c.MemoryCache.Delete(key)
+}
+
+func (c *flakyCache) Incr(key string, delta int64, ttl time.Duration) (int64, error) {
+    if err := c.check(); err != nil {
+        return 0, err
+    }
+    return c.MemoryCache.Incr(key, delta, ttl)
+}
+
+func TestFallbackCacheFailsOver(t *testing.T) {
+    primary := newFlakyCache()
+    cache := NewFallbackCache(primary, NewMemoryCache(100))
+    
+    primary.setDown(true)
+    if err := cache.Set("k", []byte("v"), time.Hour); err != nil {
+        t.Fatalf("Set during outage = %v, want fallback to succeed", err)
+    }
+    value, err := cache.Get("k")
+    if err != nil || string(value) != "v" {
+        t.Fatalf("Get during outage = %q, %v; want v from the fallback", value, err)
+    }
+    
+    // Once marked down the primary is skipped until the retry interval
+    calls := primary.callCount()
+    cache.Get("k")
+    cache.Delete("k")
+    if got := primary.callCount(); got != calls {
+        t.Fatalf("primary called %d times while marked down", got-calls)
+    }
+}
+
+func TestFallbackCacheReplaysMissedDeletes(t *testing.T) {
+    primary := newFlakyCache()
+    cache := NewFallbackCache(primary, NewMemoryCache(100))
+    
+    if err := cache.Set("link", []byte("old"), time.Hour); err != nil {
+        t.Fatal(err)
+    }
+    
+    primary.setDown(true)
+    cache.Get("link")
+    if err := cache.Delete("link"); err != nil {
+        t.Fatalf("Delete during outage = %v, want it queued", err)
+    }
+    
+    // Recover without waiting out the retry interval
+    primary.setDown(false)
+    cache.retryAt.Store(0)
+    
+    if value, err := cache.Get("link"); err != ErrCacheMiss {
+        t.Fatalf("Get after recovery = %q, %v; want the stale entry deleted", value, err)
+    }
+}
@@ -0,0 +1,182 @@
+package storage
+
+import (
+    "hash/fnv"
+    "log"
+    "sync"
+    "time"
+)
+
+const clickShards = 32
+
+// ClickBuffer accumulates click increments in memory and writes them to the
+// URLStore in batches, so a popular link costs one UPDATE per flush instead
+// of one per redirect.
+//
+// Every click is also added to a shared pending counter in the cache, so
+// any instance can report persisted + pending without waiting for the
+// others to flush. A flush takes its batch out of the shared counters
+// before writing it, so other instances may briefly miss a batch being
+// written but never count it twice.
+type ClickBuffer struct {
+    store  URLStore
+    cache  *URLCache
+    shards [clickShards]clickShard
+
+    // flushMu is held by Flush for writing and by Read for reading, so a
+    // reader never sees a batch both pending and persisted
+    flushMu sync.RWMutex
+
+    stop chan struct{}
+    done chan struct{}
+}
+
+type clickShard struct {
+    mu     sync.Mutex
+    counts map[string]ClickCounts
+}
+
+func NewClickBuffer(store URLStore, cache *URLCache) *ClickBuffer {
+    b := &ClickBuffer{store: store, cache: cache}
+    for i := range b.shards {
+        b.shards[i].counts = make(map[string]ClickCounts)
+    }
+    return b
+}
+
+func (b *ClickBuffer) shard(shortCode string) *clickShard {
+    h := fnv.New32a()
+    h.Write([]byte(shortCode))
+    return &b.shards[h.Sum32()%clickShards]
+}
+
+// Add records one click for shortCode, counted as bot traffic if bot is set
+func (b *ClickBuffer) Add(shortCode string, bot bool) {
+    shard := b.shard(shortCode)
+    shard.mu.Lock()
+    counts := shard.counts[shortCode]
+    if bot {
+        counts.Bot++
+    } else {
+        counts.Human++
+    }
+    shard.counts[shortCode] = counts
+    shard.mu.Unlock()
+    
+    // Synchronous so a flush can't take the click out of the shared
+    // counter before it was added
+    b.cache.AddPendingClicks(shortCode, bot, 1)
+}
+
+// Read calls read while no flush is running. Clicks loaded from the store
+// inside read plus Pending count every batch exactly once. read must not
+// call Read again.
+func (b *ClickBuffer) Read(read func()) {
+    b.flushMu.RLock()
+    defer b.flushMu.RUnlock()
+    read()
+}
+
+// Pending returns clicks recorded for shortCode but not yet persisted; call
+// it inside Read. The shared counters cover all instances; the local count
+// is used when it is higher, which happens if a shared counter was lost.
+func (b *ClickBuffer) Pending(shortCode string) ClickCounts {
+    shard := b.shard(shortCode)
+    shard.mu.Lock()
+    pending := shard.counts[shortCode]
+    shard.mu.Unlock()
+    
+    if shared, err := b.cache.PendingClicks(shortCode, false); err == nil && shared > pending.Human {
+        pending.Human = shared
+    }
+    if shared, err := b.cache.PendingClicks(shortCode, true); err == nil && shared > pending.Bot {
+        pending.Bot = shared
+    }
+    return pending
+}
+
+// Flush writes all pending clicks to the store. On failure the counts are
+// put back so they are retried on the next flush.
+func (b *ClickBuffer) Flush() error {
+    b.flushMu.Lock()
+    defer b.flushMu.Unlock()
+
+    batch := make(map[string]ClickCounts)
+    for i := range b.shards {
+        shard := &b.shards[i]
+        shard.mu.Lock()
+        for code, n := range shard.counts {
+            batch[code] = n
+        }
+        shard.counts = make(map[string]ClickCounts)
+        shard.mu.Unlock()
+    }
+
+    if len(batch) == 0 {
+        return nil
+    }
+
+    for code, n := range batch {
+        b.addShared(code, n, -1)
+    }
+
+    err := b.store.AddClicks(batch)
+    if err != nil {
+        for code, n := range batch {
+            shard := b.shard(code)
+            shard.mu.Lock()
+            counts := shard.counts[code]
+            counts.Human += n.Human
+            counts.Bot += n.Bot
+            shard.counts[code] = counts
+            shard.mu.Unlock()
+
+            b.addShared(code, n, 1)
+        }
+    }
+
+    return err
+}
+
+// addShared adds n times sign to the shared pending counters of shortCode
+func (b *ClickBuffer) addShared(shortCode string, n ClickCounts, sign int64) {
+    if n.Human > 0 {
+        b.cache.AddPendingClicks(shortCode, false, sign*n.Human)
+    }
+    if n.Bot > 0 {
+        b.cache.AddPendingClicks(shortCode, true, sign*n.Bot)
+    }
+}
+
+// Start flushes every interval until Stop is called
+func (b *ClickBuffer) Start(interval time.Duration) {
+    b.stop = make(chan struct{})
+    b.done = make(chan struct{})
+
+    go func() {
+        defer close(b.done)
+
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-ticker.C:
+                if err := b.Flush(); err != nil {
+                    log.Println("Failed to flush click counts:", err)
+                }
+            case <-b.stop:
+                return
+            }
+        }
+    }()
+}
+
+// Stop ends the flush loop and writes whatever is still pending
+func (b *ClickBuffer) Stop() error {
+    if b.stop != nil {
+        close(b.stop)
+        <-b.done
+    }
+    return b.Flush()
+}
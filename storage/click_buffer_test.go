@@ -0,0 +1,166 @@
+package storage
+
+import (
+    "errors"
+    "testing"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+// failingStore rejects click batches while failing is set
+type failingStore struct {
+    *MemoryStore
+    failing bool
+}
+
+func (s *failingStore) AddClicks(counts map[string]ClickCounts) error {
+    if s.failing {
+        return errors.New("store unavailable")
+    }
+    return s.MemoryStore.AddClicks(counts)
+}
+
+func newTestBuffer(t *testing.T, store URLStore) *ClickBuffer {
+    t.Helper()
+    
+    if err := store.CreateURL(&models.URL{ShortCode: "popular", OriginalURL: "https://example.com"}); err != nil {
+        t.Fatal(err)
+    }
+    return NewClickBuffer(store, NewURLCache(NewMemoryCache(100)))
+}
+
+func TestClickBufferFlush(t *testing.T) {
+    store := NewMemoryStore()
+    buffer := newTestBuffer(t, store)
+    
+    buffer.Add("popular", false)
+    buffer.Add("popular", false)
+    buffer.Add("popular", true)
+    
+    if got := buffer.Pending("popular"); got.Human != 2 || got.Bot != 1 {
+        t.Fatalf("Pending before flush = %+v, want 2 human, 1 bot", got)
+    }
+    
+    if err := buffer.Flush(); err != nil {
+        t.Fatal(err)
+    }
+    
+    if got := buffer.Pending("popular"); got.Human != 0 || got.Bot != 0 {
+        t.Fatalf("Pending after flush = %+v, want none", got)
+    }
+    
+    url, err := store.GetURL("popular")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if url.Clicks != 2 || url.BotClicks != 1 {
+        t.Fatalf("stored clicks = %d human, %d bot; want 2, 1", url.Clicks, url.BotClicks)
+    }
+}
+
+func TestClickBufferFlushFailureKeepsCounts(t *testing.T) {
+    store := &failingStore{MemoryStore: NewMemoryStore(), failing: true}
+    buffer := newTestBuffer(t, store)
+    
+    buffer.Add("popular", false)
+    buffer.Add("popular", false)
+    
+    if err := buffer.Flush(); err == nil {
+        t.Fatal("Flush succeeded against a failing store")
+    }
+    if got := buffer.Pending("popular"); got.Human != 2 {
+        t.Fatalf("Pending after failed flush = %+v, want 2 human", got)
+    }
+    
+    store.failing = false
+    if err := buffer.Flush(); err != nil {
+        t.Fatal(err)
+    }
+    
+    url, err := store.GetURL("popular")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if url.Clicks != 2 {
+        t.Fatalf("stored clicks = %d, want 2 after retry", url.Clicks)
+    }
+}
+
+func TestClickBufferSharedAcrossInstances(t *testing.T) {
+    store := NewMemoryStore()
+    cache := NewURLCache(NewMemoryCache(100))
+    if err := store.CreateURL(&models.URL{ShortCode: "popular", OriginalURL: "https://example.com"}); err != nil {
+        t.Fatal(err)
+    }
+    first := NewClickBuffer(store, cache)
+    second := NewClickBuffer(store, cache)
+    
+    first.Add("popular", false)
+    if got := second.Pending("popular"); got.Human != 1 {
+        t.Fatalf("other instance sees %d pending, want 1", got.Human)
+    }
+    
+    if err := first.Flush(); err != nil {
+        t.Fatal(err)
+    }
+    if got := second.Pending("popular"); got.Human != 0 {
+        t.Fatalf("other instance sees %d pending after flush, want 0", got.Human)
+    }
+}
+
+func TestClickBufferReadCountsEachClickOnce(t *testing.T) {
+    store := NewMemoryStore()
+    buffer := newTestBuffer(t, store)
+    
+    const clicks = 500
+    done := make(chan struct{})
+    go func() {
+        defer close(done)
+        for i := 0; i < clicks; i++ {
+            buffer.Add("popular", false)
+            if i%7 == 0 {
+                buffer.Flush()
+            }
+        }
+        buffer.Flush()
+    }()
+    
+    var last int64
+    for finished := false; !finished; {
+        select {
+        case <-done:
+            finished = true
+        default:
+        }
+        
+        var total int64
+        buffer.Read(func() {
+            url, err := store.GetURL("popular")
+            if err != nil {
+                t.Error(err)
+                return
+            }
+            total = url.Clicks + buffer.Pending("popular").Human
+        })
+        if total < last || total > clicks {
+            t.Fatalf("total went from %d to %d", last, total)
+        }
+        last = total
+    }
+    
+    if last != clicks {
+        t.Fatalf("final total = %d, want %d", last, clicks)
+    }
+}
+
+func TestPendingClicksSettleAtZero(t *testing.T) {
+    cache := NewURLCache(NewMemoryCache(100))
+    
+    // The increments were lost, e.g. the counter expired before the flush
+    if n, err := cache.AddPendingClicks("popular", false, -3); err != nil || n != 0 {
+        t.Fatalf("AddPendingClicks = %d, %v; want 0", n, err)
+    }
+    if n, _ := cache.AddPendingClicks("popular", false, 1); n != 1 {
+        t.Fatalf("counter after a new click = %d, want 1", n)
+    }
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+    "log"
+    "sync/atomic"
+    "time"
+
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+// maxInsertAttempts is how many flushes a failed batch is retried whole
+// before it is split to isolate the events the store rejects
+const maxInsertAttempts = 3
+
+// ClickEventWriter queues click events and inserts them in batches from a
+// single background goroutine. Record never blocks: when the queue is full
+// the event is dropped and counted, so analytics can never slow a redirect.
+//
+// A batch the store rejects is kept and retried, holding at most a queue's
+// worth of events meanwhile. If it keeps failing it is split in halves so
+// that only the events rejected on their own are dropped.
+type ClickEventWriter struct {
+    store     ClickStore
+    events    chan models.ClickEvent
+    batchSize int
+    interval  time.Duration
+    dropped   atomic.Int64
+
+    stop chan struct{}
+    done chan struct{}
+}
+
+func NewClickEventWriter(store ClickStore, bufferSize, batchSize int, interval time.Duration) *ClickEventWriter {
+    return &ClickEventWriter{
+        store:     store,
+        events:    make(chan models.ClickEvent, bufferSize),
+        batchSize: batchSize,
+        interval:  interval,
+        stop:      make(chan struct{}),
+        done:      make(chan struct{}),
+    }
+}
+
+// Record queues event, reporting false if it had to be dropped
+func (w *ClickEventWriter) Record(event models.ClickEvent) bool {
+    select {
+    case w.events <- event:
+        return true
+    default:
+        w.dropped.Add(1)
+        return false
+    }
+}
+
+// Dropped returns how many events have been dropped since start, whether
+// the queue was full or the store rejected them
+func (w *ClickEventWriter) Dropped() int64 {
+    return w.dropped.Load()
+}
+
+// Start runs the batching loop until Stop is called
+func (w *ClickEventWriter) Start() {
+    go w.run()
+}
+
+// Stop writes everything still queued and waits for the loop to exit
+func (w *ClickEventWriter) Stop() {
+    close(w.stop)
+    <-w.done
+}
+
+func (w *ClickEventWriter) run() {
+    defer close(w.done)
+
+    ticker := time.NewTicker(w.interval)
+    defer ticker.Stop()
+
+    batch := make([]models.ClickEvent, 0, w.batchSize)
+    attempts := 0
+    var reportedDrops int64
+
+    flush := func(final bool) {
+        if len(batch) == 0 {
+            return
+        }
+        if err := w.store.InsertClickEvents(batch); err != nil {
+            attempts++
+            if attempts < maxInsertAttempts && !final {
+                log.Printf("Failed to write %d click events, will retry: %v", len(batch), err)
+                return
+            }
+            log.Printf("Failed to write %d click events, writing them in parts: %v", len(batch), err)
+            w.insertSplit(batch)
+        }
+        batch = batch[:0]
+        attempts = 0
+    }
+
+    add := func(event models.ClickEvent) {
+        if len(batch) >= cap(w.events)+w.batchSize {
+            w.dropped.Add(1)
+            return
+        }
+        batch = append(batch, event)
+
+        // While a failed batch waits for its retry the ticker drives flushes
+        if attempts == 0 && len(batch) >= w.batchSize {
+            flush(false)
+        }
+    }
+
+    for {
+        select {
+        case event := <-w.events:
+            add(event)
+
+        case <-ticker.C:
+            flush(false)
+
+            if dropped := w.dropped.Load(); dropped > reportedDrops {
+                log.Printf("Click event queue full or rejected, dropped %d events", dropped-reportedDrops)
+                reportedDrops = dropped
+            }
+
+        case <-w.stop:
+            for {
+                select {
+                case event := <-w.events:
+                    add(event)
+                default:
+                    flush(true)
+                    return
+                }
+            }
+        }
+    }
+}
+
+// insertSplit writes a rejected batch in halves, recursing into any half
+// that fails, so one bad event only costs itself. Events rejected on
+// their own are dropped and counted.
+func (w *ClickEventWriter) insertSplit(events []models.ClickEvent) {
+    if len(events) == 1 {
+        w.dropped.Add(1)
+        return
+    }
+
+    mid := len(events) / 2
+    for _, half := range [][]models.ClickEvent{events[:mid], events[mid:]} {
+        if err := w.store.InsertClickEvents(half); err != nil {
+            w.insertSplit(half)
+        }
+    }
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+    "errors"
+    "sync"
+    "testing"
+    "time"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+// rejectingClickStore fails whole batches that contain a "bad" event, as
+// Postgres does for a value too long for its column, and fails the first
+// outages calls to simulate the store being down
+type rejectingClickStore struct {
+    *MemoryStore
+    mu       sync.Mutex
+    outages  int
+    inserted int
+}
+
+func (s *rejectingClickStore) InsertClickEvents(events []models.ClickEvent) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    if s.outages > 0 {
+        s.outages--
+        return errors.New("store unavailable")
+    }
+    for _, event := range events {
+        if event.AcceptLanguage == "bad" {
+            return errors.New("value too long")
+        }
+    }
+    s.inserted += len(events)
+    return nil
+}
+
+func (s *rejectingClickStore) count() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.inserted
+}
+
+func TestClickEventWriterIsolatesRejectedEvent(t *testing.T) {
+    store := &rejectingClickStore{MemoryStore: NewMemoryStore()}
+    writer := NewClickEventWriter(store, 100, 50, time.Hour)
+    writer.Start()
+    
+    for i := 0; i < 10; i++ {
+        event := models.ClickEvent{ShortCode: "abc123", ClickedAt: time.Now()}
+        if i == 4 {
+            event.AcceptLanguage = "bad"
+        }
+        writer.Record(event)
+    }
+    writer.Stop()
+    
+    if got := store.count(); got != 9 {
+        t.Fatalf("inserted %d events, want 9", got)
+    }
+    if got := writer.Dropped(); got != 1 {
+        t.Fatalf("dropped %d events, want 1", got)
+    }
+}
+
+func TestClickEventWriterRetriesFailedBatch(t *testing.T) {
+    store := &rejectingClickStore{MemoryStore: NewMemoryStore(), outages: maxInsertAttempts - 1}
+    writer := NewClickEventWriter(store, 100, 5, 10*time.Millisecond)
+    writer.Start()
+    
+    for i := 0; i < 5; i++ {
+        writer.Record(models.ClickEvent{ShortCode: "abc123", ClickedAt: time.Now()})
+    }
+    
+    deadline := time.Now().Add(2 * time.Second)
+    for store.count() < 5 && time.Now().Before(deadline) {
+        time.Sleep(5 * time.Millisecond)
+    }
+    writer.Stop()
+    
+    if got := store.count(); got != 5 {
+        t.Fatalf("inserted %d events after the outage, want 5", got)
+    }
+    if got := writer.Dropped(); got != 0 {
+        t.Fatalf("dropped %d events, want 0", got)
+    }
+}
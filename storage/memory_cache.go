@@ -0,0 +1,133 @@
+package storage
+
+import (
+    "container/list"
+    "strconv"
+    "sync"
+    "time"
+)
+
+// MemoryCache is an in-process LRU cache with per-entry expiry
+type MemoryCache struct {
+    mu         sync.Mutex
+    maxEntries int
+    order      *list.List
+    entries    map[string]*list.Element
+}
+
+type memoryEntry struct {
+    key       string
+    value     []byte
+    expiresAt time.Time
+}
+
+// NewMemoryCache creates a cache holding at most maxEntries keys
+func NewMemoryCache(maxEntries int) *MemoryCache {
+    return &MemoryCache{
+        maxEntries: maxEntries,
+        order:      list.New(),
+        entries:    make(map[string]*list.Element),
+    }
+}
+
+func (c *MemoryCache) Get(key string) ([]byte, error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    
+    entry := c.lookup(key)
+    if entry == nil {
+        return nil, ErrCacheMiss
+    }
+    
+    return entry.value, nil
+}
+
+func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    
+    c.store(key, value, expiryFor(ttl))
+    return nil
+}
+
+func (c *MemoryCache) Delete(key string) error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    
+    if elem, ok := c.entries[key]; ok {
+        c.remove(elem)
+    }
+    return nil
+}
+
+func (c *MemoryCache) Incr(key string, delta int64, ttl time.Duration) (int64, error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    
+    var current int64
+    expiresAt := expiryFor(ttl)
+    
+    if entry := c.lookup(key); entry != nil {
+        n, err := strconv.ParseInt(string(entry.value), 10, 64)
+        if err != nil {
+            return 0, err
+        }
+        current = n
+        if !entry.expiresAt.IsZero() {
+            expiresAt = entry.expiresAt
+        }
+    }
+    
+    current += delta
+    c.store(key, []byte(strconv.FormatInt(current, 10)), expiresAt)
+    return current, nil
+}
+
+// lookup returns the live entry for key, dropping it if expired
+func (c *MemoryCache) lookup(key string) *memoryEntry {
+    elem, ok := c.entries[key]
+    if !ok {
+        return nil
+    }
+    
+    entry := elem.Value.(*memoryEntry)
+    if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+        c.remove(elem)
+        return nil
+    }
+    
+    c.order.MoveToFront(elem)
+    return entry
+}
+
+func (c *MemoryCache) store(key string, value []byte, expiresAt time.Time) {
+    if elem, ok := c.entries[key]; ok {
+        entry := elem.Value.(*memoryEntry)
+        entry.value = value
+        entry.expiresAt = expiresAt
+        c.order.MoveToFront(elem)
+        return
+    }
+    
+    c.entries[key] = c.order.PushFront(&memoryEntry{
+        key:       key,
+        value:     value,
+        expiresAt: expiresAt,
+    })
+    
+    for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+        c.remove(c.order.Back())
+    }
+}
+
+func (c *MemoryCache) remove(elem *list.Element) {
+    c.order.Remove(elem)
+    delete(c.entries, elem.Value.(*memoryEntry).key)
+}
+
+func expiryFor(ttl time.Duration) time.Time {
+    if ttl <= 0 {
+        return time.Time{}
+    }
+    return time.Now().Add(ttl)
+}
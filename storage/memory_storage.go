@@ -0,0 +1,355 @@
+package storage
+
+import (
+    "errors"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+// MemoryStore implements URLStore and UserStore in process memory.
+// Data is lost on restart, so it is meant for local development and demos.
+type MemoryStore struct {
+    mu         sync.RWMutex
+    users      map[int]*models.User
+    urls       map[string]*models.URL
+    archived   map[string]*models.URL
+    reserved   map[string]struct{}
+    clicks     []models.ClickEvent
+    rollups    map[rollupKey]ClickCounts
+    nextUserID int
+    nextURLID  int
+}
+
+func NewMemoryStore() *MemoryStore {
+    return &MemoryStore{
+        users:    make(map[int]*models.User),
+        urls:     make(map[string]*models.URL),
+        archived: make(map[string]*models.URL),
+        reserved: make(map[string]struct{}),
+        rollups:  make(map[rollupKey]ClickCounts),
+    }
+}
+
+
+func (s *MemoryStore) CreateUser(user *models.User) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for _, existing := range s.users {
+        if existing.Username == user.Username || existing.Email == user.Email {
+            return errors.New("user already exists")
+        }
+    }
+
+    s.nextUserID++
+    user.ID = s.nextUserID
+    user.CreatedAt = time.Now()
+
+    stored := *user
+    s.users[user.ID] = &stored
+    return nil
+}
+
+
+func (s *MemoryStore) GetUserByEmail(email string) (*models.User, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    for _, user := range s.users {
+        if user.Email == email {
+            found := *user
+            return &found, nil
+        }
+    }
+
+    return nil, errors.New("user not found")
+}
+
+
+func (s *MemoryStore) GetUserByID(id int) (*models.User, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    user, ok := s.users[id]
+    if !ok {
+        return nil, errors.New("user not found")
+    }
+
+    found := *user
+    return &found, nil
+}
+
+
+func (s *MemoryStore) CreateURL(url *models.URL) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if _, exists := s.urls[url.ShortCode]; exists {
+        return errors.New("short code already exists")
+    }
+
+    s.nextURLID++
+    url.ID = s.nextURLID
+    url.Clicks = 0
+    url.CreatedAt = time.Now()
+
+    stored := *url
+    s.urls[url.ShortCode] = &stored
+    return nil
+}
+
+func (s *MemoryStore) GetURLByShortCode(shortCode string) (*models.URL, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    url, ok := s.urls[shortCode]
+    if !ok {
+        if _, archived := s.archived[shortCode]; archived {
+            return nil, ErrExpired
+        }
+        return nil, ErrNotFound
+    }
+    if err := CheckAvailable(url, time.Now()); err != nil {
+        return nil, err
+    }
+
+    found := *url
+    return &found, nil
+}
+
+func (s *MemoryStore) GetURL(shortCode string) (*models.URL, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    url, ok := s.urls[shortCode]
+    if !ok {
+        return nil, ErrNotFound
+    }
+
+    found := *url
+    return &found, nil
+}
+
+func (s *MemoryStore) UpdateURL(url *models.URL) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    stored, ok := s.urls[url.ShortCode]
+    if !ok {
+        return ErrNotFound
+    }
+    if stored.Version != url.Version {
+        return ErrConflict
+    }
+
+    stored.OriginalURL = url.OriginalURL
+    stored.StartsAt = url.StartsAt
+    stored.ExpiresAt = url.ExpiresAt
+    stored.Status = url.Status
+    stored.MaxClicks = url.MaxClicks
+    stored.PasswordHash = url.PasswordHash
+    stored.RedirectStatus = url.RedirectStatus
+    stored.Title = url.Title
+    stored.Targeting = url.Targeting
+    stored.GeoTargeting = url.GeoTargeting
+    stored.Variants = url.Variants
+    stored.Type = url.Type
+    stored.DeepLink = url.DeepLink
+    stored.Version++
+    url.Version = stored.Version
+    return nil
+}
+
+func (s *MemoryStore) ConsumeRedirect(shortCode string) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    url, ok := s.urls[shortCode]
+    if !ok || (url.MaxClicks != nil && url.RedirectCount >= *url.MaxClicks) {
+        return false, nil
+    }
+
+    url.RedirectCount++
+    return true, nil
+}
+
+func (s *MemoryStore) DeleteURL(shortCode string, at time.Time) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if url, ok := s.urls[shortCode]; ok {
+        url.DeletedAt = &at
+    }
+    return nil
+}
+
+func (s *MemoryStore) RestoreURL(shortCode string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if url, ok := s.urls[shortCode]; ok {
+        url.DeletedAt = nil
+    }
+    return nil
+}
+
+func (s *MemoryStore) AddClicks(counts map[string]ClickCounts) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for code, n := range counts {
+        if url, ok := s.urls[code]; ok {
+            url.Clicks += n.Human
+            url.BotClicks += n.Bot
+        }
+    }
+    return nil
+}
+
+func (s *MemoryStore) GetUserURLs(userID int) ([]models.URL, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    var urls []models.URL
+    for _, url := range s.urls {
+        if url.UserID != nil && *url.UserID == userID {
+            urls = append(urls, *url)
+        }
+    }
+
+    sort.Slice(urls, func(i, j int) bool {
+        return urls[i].CreatedAt.After(urls[j].CreatedAt)
+    })
+
+    return urls, nil
+}
+
+func (s *MemoryStore) ShortCodeExists(shortCode string) (bool, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    _, exists := s.urls[shortCode]
+    _, archived := s.archived[shortCode]
+    _, reserved := s.reserved[shortCode]
+    return exists || archived || reserved, nil
+}
+
+func (s *MemoryStore) ReapExpiredURLs(expiredBefore time.Time, archive bool) (int64, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    var n int64
+    for code, url := range s.urls {
+        if url.ExpiresAt == nil || !url.ExpiresAt.Before(expiredBefore) {
+            continue
+        }
+        if archive {
+            s.archived[code] = url
+        } else {
+            s.reserved[code] = struct{}{}
+        }
+        delete(s.urls, code)
+        n++
+    }
+    return n, nil
+}
+
+func (s *MemoryStore) InsertClickEvents(events []models.ClickEvent) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for _, event := range events {
+        event.ID = int64(len(s.clicks) + 1)
+        s.clicks = append(s.clicks, event)
+    }
+
+    for key, n := range hourlyRollups(events) {
+        counts := s.rollups[key]
+        counts.Human += n.Human
+        counts.Bot += n.Bot
+        s.rollups[key] = counts
+    }
+    return nil
+}
+
+func (s *MemoryStore) ClickCountsByMinute(shortCode string, filter ClickFilter) ([]models.ClickBucket, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    counts := make(map[time.Time]int64)
+    for _, event := range s.clicks {
+        if event.ShortCode != shortCode || !filter.matches(event) {
+            continue
+        }
+        counts[event.ClickedAt.UTC().Truncate(time.Minute)]++
+    }
+
+    return sortedBuckets(counts), nil
+}
+
+func (s *MemoryStore) ClickCountsByHour(shortCode string, filter ClickFilter) ([]models.ClickBucket, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    counts := make(map[time.Time]int64)
+    for key, n := range s.rollups {
+        if key.shortCode != shortCode || key.hour.Before(filter.From) || !key.hour.Before(filter.To) {
+            continue
+        }
+        counts[key.hour] = n.Human
+        if filter.IncludeBots {
+            counts[key.hour] += n.Bot
+        }
+    }
+
+    return sortedBuckets(counts), nil
+}
+
+func (s *MemoryStore) ClickBreakdown(shortCode, dimension string, filter ClickFilter, limit int) ([]models.BreakdownEntry, error) {
+    if _, ok := breakdownColumns[dimension]; !ok {
+        return nil, errors.New("unknown breakdown dimension")
+    }
+
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    counts := make(map[string]int64)
+    for _, event := range s.clicks {
+        if event.ShortCode != shortCode || !filter.matches(event) {
+            continue
+        }
+        counts[breakdownValue(event, dimension)]++
+    }
+
+    entries := make([]models.BreakdownEntry, 0, len(counts))
+    for value, n := range counts {
+        entries = append(entries, models.BreakdownEntry{Value: value, Clicks: n})
+    }
+
+    sort.Slice(entries, func(i, j int) bool {
+        if entries[i].Clicks != entries[j].Clicks {
+            return entries[i].Clicks > entries[j].Clicks
+        }
+        return entries[i].Value < entries[j].Value
+    })
+
+    if len(entries) > limit {
+        entries = entries[:limit]
+    }
+    return entries, nil
+}
+
+func sortedBuckets(counts map[time.Time]int64) []models.ClickBucket {
+    buckets := make([]models.ClickBucket, 0, len(counts))
+    for start, n := range counts {
+        buckets = append(buckets, models.ClickBucket{Start: start, Clicks: n})
+    }
+
+    sort.Slice(buckets, func(i, j int) bool {
+        return buckets[i].Start.Before(buckets[j].Start)
+    })
+    return buckets
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+    "testing"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+func TestMemoryStoreUsers(t *testing.T) {
+    store := NewMemoryStore()
+    
+    user := &models.User{Username: "alice", Email: "alice@example.com", PasswordHash: "hash"}
+    if err := store.CreateUser(user); err != nil {
+        t.Fatal(err)
+    }
+    if user.ID == 0 || user.CreatedAt.IsZero() {
+        t.Fatalf("CreateUser did not assign ID and CreatedAt: %+v", user)
+    }
+    
+    duplicate := &models.User{Username: "alice2", Email: "alice@example.com", PasswordHash: "hash"}
+    if err := store.CreateUser(duplicate); err == nil {
+        t.Fatal("CreateUser accepted a duplicate email")
+    }
+    
+    byEmail, err := store.GetUserByEmail("alice@example.com")
+    if err != nil || byEmail.ID != user.ID {
+        t.Fatalf("GetUserByEmail = %+v, %v", byEmail, err)
+    }
+    byID, err := store.GetUserByID(user.ID)
+    if err != nil || byID.Email != user.Email {
+        t.Fatalf("GetUserByID = %+v, %v", byID, err)
+    }
+    if _, err := store.GetUserByID(user.ID + 1); err == nil {
+        t.Fatal("GetUserByID found a user that does not exist")
+    }
+}
+
+func TestMemoryStoreURLs(t *testing.T) {
+    store := NewMemoryStore()
+    owner := 7
+    
+    url := &models.URL{ShortCode: "abc123", OriginalURL: "https://example.com", UserID: &owner}
+    if err := store.CreateURL(url); err != nil {
+        t.Fatal(err)
+    }
+    if err := store.CreateURL(&models.URL{ShortCode: "abc123", OriginalURL: "https://example.org"}); err == nil {
+        t.Fatal("CreateURL accepted a duplicate short code")
+    }
+    if err := store.CreateURL(&models.URL{ShortCode: "anon", OriginalURL: "https://example.org"}); err != nil {
+        t.Fatal(err)
+    }
+    
+    found, err := store.GetURLByShortCode("abc123")
+    if err != nil || found.OriginalURL != "https://example.com" {
+        t.Fatalf("GetURLByShortCode = %+v, %v", found, err)
+    }
+    if _, err := store.GetURLByShortCode("missing"); err != ErrNotFound {
+        t.Fatalf("GetURLByShortCode(missing) error = %v, want ErrNotFound", err)
+    }
+    
+    // Callers get copies, so changing one must not change the store
+    found.OriginalURL = "https://changed.example"
+    again, _ := store.GetURL("abc123")
+    if again.OriginalURL != "https://example.com" {
+        t.Fatal("modifying a returned link changed the stored one")
+    }
+    
+    urls, err := store.GetUserURLs(owner)
+    if err != nil || len(urls) != 1 || urls[0].ShortCode != "abc123" {
+        t.Fatalf("GetUserURLs = %+v, %v; want only abc123", urls, err)
+    }
+    
+    for code, want := range map[string]bool{"abc123": true, "anon": true, "missing": false} {
+        if exists, _ := store.ShortCodeExists(code); exists != want {
+            t.Errorf("ShortCodeExists(%q) = %v, want %v", code, exists, want)
+        }
+    }
+}
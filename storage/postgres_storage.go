@@ -5,19 +5,28 @@ import (
     "errors"
     "time"
     
-    "github.com/heydeepakch/url-shortner-golang/database"
+    "github.com/lib/pq"
+    
     "github.com/heydeepakch/url-shortner-golang/models"
 )
 
+// PostgresStore implements URLStore and UserStore on top of PostgreSQL
+type PostgresStore struct {
+    db *sql.DB
+}
 
-func CreateUser(user *models.User) error {
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+    return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) CreateUser(user *models.User) error {
     query := `
         INSERT INTO users (username, email, password_hash, created_at)
         VALUES ($1, $2, $3, $4)
         RETURNING id
     `
     
-    err := database.DB.QueryRow(
+    err := s.db.QueryRow(
         query,
         user.Username,
         user.Email,
@@ -29,7 +38,7 @@ func CreateUser(user *models.User) error {
 }
 
 
-func GetUserByEmail(email string) (*models.User, error) {
+func (s *PostgresStore) GetUserByEmail(email string) (*models.User, error) {
     query := `
         SELECT id, username, email, password_hash, created_at
         FROM users
@@ -37,7 +46,7 @@ func GetUserByEmail(email string) (*models.User, error) {
     `
     
     user := &models.User{}
-    err := database.DB.QueryRow(query, email).Scan(
+    err := s.db.QueryRow(query, email).Scan(
         &user.ID,
         &user.Username,
         &user.Email,
@@ -53,7 +62,7 @@ func GetUserByEmail(email string) (*models.User, error) {
 }
 
 
-func GetUserByID(id int) (*models.User, error) {
+func (s *PostgresStore) GetUserByID(id int) (*models.User, error) {
     query := `
         SELECT id, username, email, password_hash, created_at
         FROM users
@@ -61,7 +70,7 @@ func GetUserByID(id int) (*models.User, error) {
     `
     
     user := &models.User{}
-    err := database.DB.QueryRow(query, id).Scan(
+    err := s.db.QueryRow(query, id).Scan(
         &user.ID,
         &user.Username,
         &user.Email,
@@ -77,67 +86,252 @@ func GetUserByID(id int) (*models.User, error) {
 }
 
 
-func CreateURL(url *models.URL) error {
+func (s *PostgresStore) CreateURL(url *models.URL) error {
+    // Set before caching so the cached copy carries it too
+    url.CreatedAt = time.Now()
+    
+    targeting, err := encodeTargeting(url.Targeting)
+    if err != nil {
+        return err
+    }
+    
+    geoTargeting, err := encodeGeoTargeting(url.GeoTargeting)
+    if err != nil {
+        return err
+    }
+    
+    variants, err := encodeVariants(url.Variants)
+    if err != nil {
+        return err
+    }
+    
+    deepLink, err := encodeDeepLink(url.DeepLink)
+    if err != nil {
+        return err
+    }
+    
     query := `
-        INSERT INTO urls (short_code, original_url, user_id, clicks, created_at, expires_at)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO urls (
+            short_code, original_url, user_id, status, clicks, max_clicks,
+            created_at, starts_at, expires_at, password_hash, redirect_status,
+            title, targeting, geo_targeting, variants, link_type, deep_link
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
         RETURNING id
     `
     
-    err := database.DB.QueryRow(
+    err = s.db.QueryRow(
         query,
         url.ShortCode,
         url.OriginalURL,
         url.UserID,
+        url.Status,
         0,
-        time.Now(),
-        url.ExpiresAt,
+        url.MaxClicks,
+        url.CreatedAt.UTC(),
+        utcTime(url.StartsAt),
+        utcTime(url.ExpiresAt),
+        nullString(url.PasswordHash),
+        url.RedirectStatus,
+        nullString(url.Title),
+        targeting,
+        geoTargeting,
+        variants,
+        url.Type,
+        deepLink,
     ).Scan(&url.ID)
     
     return err
 }
 
-func GetURLByShortCode(shortCode string) (*models.URL, error) {
+// GetURLByShortCode compares against the current time in UTC, the zone
+// every timestamp is written in
+func (s *PostgresStore) GetURLByShortCode(shortCode string) (*models.URL, error) {
     query := `
-        SELECT id, short_code, original_url, user_id, clicks, created_at, expires_at
+        SELECT ` + urlColumns + `
         FROM urls
         WHERE short_code = $1 
-        AND (expires_at IS NULL OR expires_at > NOW())
+        AND deleted_at IS NULL
+        AND status = 'active'
+        AND (starts_at IS NULL OR starts_at <= NOW() AT TIME ZONE 'UTC')
+        AND (expires_at IS NULL OR expires_at > NOW() AT TIME ZONE 'UTC')
     `
     
-    url := &models.URL{}
-    err := database.DB.QueryRow(query, shortCode).Scan(
-        &url.ID,
-        &url.ShortCode,
-        &url.OriginalURL,
-        &url.UserID,
-        &url.Clicks,
-        &url.CreatedAt,
-        &url.ExpiresAt,
-    )
+    url, err := scanURL(s.db.QueryRow(query, shortCode))
+    
+    if err == sql.ErrNoRows {
+        return s.unavailable(shortCode)
+    }
+    
+    return url, err
+}
+
+// unavailable looks up a link the live filter skipped to report why
+func (s *PostgresStore) unavailable(shortCode string) (*models.URL, error) {
+    url, err := s.GetURL(shortCode)
+    if err == ErrNotFound {
+        var archived bool
+        query := `SELECT EXISTS(SELECT 1 FROM urls_archive WHERE short_code = $1)`
+        if err := s.db.QueryRow(query, shortCode).Scan(&archived); err == nil && archived {
+            return nil, ErrExpired
+        }
+    }
+    if err != nil {
+        return nil, err
+    }
+    
+    if err := CheckAvailable(url, time.Now()); err != nil {
+        return nil, err
+    }
+    return url, nil
+}
+
+func (s *PostgresStore) GetURL(shortCode string) (*models.URL, error) {
+    query := `
+        SELECT ` + urlColumns + `
+        FROM urls
+        WHERE short_code = $1
+    `
+    
+    url, err := scanURL(s.db.QueryRow(query, shortCode))
     
     if err == sql.ErrNoRows {
-        return nil, errors.New("URL not found or expired")
+        return nil, ErrNotFound
     }
     
     return url, err
 }
 
-func IncrementClicks(shortCode string) error {
-    query := `UPDATE urls SET clicks = clicks + 1 WHERE short_code = $1`
-    _, err := database.DB.Exec(query, shortCode)
+func (s *PostgresStore) UpdateURL(url *models.URL) error {
+    targeting, err := encodeTargeting(url.Targeting)
+    if err != nil {
+        return err
+    }
+    
+    geoTargeting, err := encodeGeoTargeting(url.GeoTargeting)
+    if err != nil {
+        return err
+    }
+    
+    variants, err := encodeVariants(url.Variants)
+    if err != nil {
+        return err
+    }
+    
+    deepLink, err := encodeDeepLink(url.DeepLink)
+    if err != nil {
+        return err
+    }
+    
+    query := `
+        UPDATE urls SET
+            original_url = $1, starts_at = $2, expires_at = $3,
+            status = $4, max_clicks = $5, password_hash = $6,
+            redirect_status = $7, title = $8, targeting = $9,
+            geo_targeting = $10, variants = $11, link_type = $12,
+            deep_link = $13, version = version + 1
+        WHERE id = $14 AND version = $15
+    `
+    
+    result, err := s.db.Exec(
+        query,
+        url.OriginalURL,
+        utcTime(url.StartsAt),
+        utcTime(url.ExpiresAt),
+        url.Status,
+        url.MaxClicks,
+        nullString(url.PasswordHash),
+        url.RedirectStatus,
+        nullString(url.Title),
+        targeting,
+        geoTargeting,
+        variants,
+        url.Type,
+        deepLink,
+        url.ID,
+        url.Version,
+    )
+    if err != nil {
+        return err
+    }
+    
+    n, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if n == 0 {
+        return ErrConflict
+    }
+    
+    url.Version++
+    return nil
+}
+
+// ConsumeRedirect relies on the row lock taken by UPDATE: concurrent
+// callers are serialised and each re-checks the budget
+func (s *PostgresStore) ConsumeRedirect(shortCode string) (bool, error) {
+    query := `
+        UPDATE urls SET redirect_count = redirect_count + 1
+        WHERE short_code = $1
+        AND (max_clicks IS NULL OR redirect_count < max_clicks)
+    `
+        
+    result, err := s.db.Exec(query, shortCode)
+    if err != nil {
+        return false, err
+    }
+        
+    n, err := result.RowsAffected()
+    return n == 1, err
+}
+
+func (s *PostgresStore) DeleteURL(shortCode string, at time.Time) error {
+    query := `UPDATE urls SET deleted_at = $1 WHERE short_code = $2`
+    _, err := s.db.Exec(query, at.UTC(), shortCode)
     return err
 }
 
-func GetUserURLs(userID int) ([]models.URL, error) {
+func (s *PostgresStore) RestoreURL(shortCode string) error {
+    query := `UPDATE urls SET deleted_at = NULL WHERE short_code = $1`
+    _, err := s.db.Exec(query, shortCode)
+    return err
+}
+
+// AddClicks applies the whole batch in a single UPDATE
+func (s *PostgresStore) AddClicks(counts map[string]ClickCounts) error {
+    if len(counts) == 0 {
+        return nil
+    }
+    
+    codes := make([]string, 0, len(counts))
+    clicks := make([]int64, 0, len(counts))
+    botClicks := make([]int64, 0, len(counts))
+    for code, n := range counts {
+        codes = append(codes, code)
+        clicks = append(clicks, n.Human)
+        botClicks = append(botClicks, n.Bot)
+    }
+    
+    query := `
+        UPDATE urls SET
+            clicks = urls.clicks + batch.clicks,
+            bot_clicks = urls.bot_clicks + batch.bot_clicks
+        FROM unnest($1::text[], $2::bigint[], $3::bigint[]) AS batch(short_code, clicks, bot_clicks)
+        WHERE urls.short_code = batch.short_code
+    `
+    _, err := s.db.Exec(query, pq.Array(codes), pq.Array(clicks), pq.Array(botClicks))
+    return err
+}
+
+func (s *PostgresStore) GetUserURLs(userID int) ([]models.URL, error) {
     query := `
-        SELECT id, short_code, original_url, user_id, clicks, created_at, expires_at
+        SELECT ` + urlColumns + `
         FROM urls
         WHERE user_id = $1
         ORDER BY created_at DESC
     `
     
-    rows, err := database.DB.Query(query, userID)
+    rows, err := s.db.Query(query, userID)
     if err != nil {
         return nil, err
     }
@@ -145,28 +339,194 @@ func GetUserURLs(userID int) ([]models.URL, error) {
     
     var urls []models.URL
     for rows.Next() {
-        var url models.URL
-        err := rows.Scan(
-            &url.ID,
-            &url.ShortCode,
-            &url.OriginalURL,
-            &url.UserID,
-            &url.Clicks,
-            &url.CreatedAt,
-            &url.ExpiresAt,
-        )
+        url, err := scanURL(rows)
         if err != nil {
             return nil, err
         }
-        urls = append(urls, url)
+        urls = append(urls, *url)
     }
     
     return urls, nil
 }
 
-func ShortCodeExists(shortCode string) (bool, error) {
-    query := `SELECT EXISTS(SELECT 1 FROM urls WHERE short_code = $1)`
+func (s *PostgresStore) ShortCodeExists(shortCode string) (bool, error) {
+    query := `
+        SELECT EXISTS(SELECT 1 FROM urls WHERE short_code = $1)
+        OR EXISTS(SELECT 1 FROM urls_archive WHERE short_code = $1)
+        OR EXISTS(SELECT 1 FROM reserved_codes WHERE short_code = $1)
+    `
     var exists bool
-    err := database.DB.QueryRow(query, shortCode).Scan(&exists)
+    err := s.db.QueryRow(query, shortCode).Scan(&exists)
     return exists, err
 }
+
+// ReapExpiredURLs deletes and archives in one statement so a link can't be
+// lost between the two. Purged links keep only their code, reserved.
+func (s *PostgresStore) ReapExpiredURLs(expiredBefore time.Time, archive bool) (int64, error) {
+    if !archive {
+        query := `
+            WITH reaped AS (
+                DELETE FROM urls WHERE expires_at < $1
+                RETURNING short_code
+            )
+            INSERT INTO reserved_codes (short_code, reserved_at)
+            SELECT short_code, NOW() AT TIME ZONE 'UTC' FROM reaped
+            ON CONFLICT (short_code) DO NOTHING
+        `
+        result, err := s.db.Exec(query, expiredBefore.UTC())
+        if err != nil {
+            return 0, err
+        }
+        return result.RowsAffected()
+    }
+    
+    query := `
+        WITH reaped AS (
+            DELETE FROM urls WHERE expires_at < $1
+            RETURNING id, short_code, original_url, user_id, clicks, bot_clicks, created_at, expires_at
+        )
+        INSERT INTO urls_archive (
+            id, short_code, original_url, user_id, clicks, bot_clicks, created_at, expires_at, archived_at
+        )
+        SELECT reaped.*, NOW() AT TIME ZONE 'UTC' FROM reaped
+    `
+    result, err := s.db.Exec(query, expiredBefore.UTC())
+    if err != nil {
+        return 0, err
+    }
+    return result.RowsAffected()
+}
+
+// InsertClickEvents bulk loads events with COPY
+func (s *PostgresStore) InsertClickEvents(events []models.ClickEvent) error {
+    if len(events) == 0 {
+        return nil
+    }
+    
+    tx, err := s.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+    
+    stmt, err := tx.Prepare(pq.CopyIn(
+        "clicks",
+        "short_code", "clicked_at", "referrer", "user_agent", "ip_hash", "accept_language", "is_bot",
+        "referrer_domain", "browser", "os", "device",
+        "country", "region", "city",
+        "variant",
+    ))
+    if err != nil {
+        return err
+    }
+    
+    for _, event := range events {
+        if _, err := stmt.Exec(
+            event.ShortCode,
+            event.ClickedAt.UTC(),
+            event.Referrer,
+            event.UserAgent,
+            event.IPHash,
+            event.AcceptLanguage,
+            event.IsBot,
+            event.ReferrerDomain,
+            event.Browser,
+            event.OS,
+            event.Device,
+            event.Country,
+            event.Region,
+            event.City,
+            nullString(event.Variant),
+        ); err != nil {
+            stmt.Close()
+            return err
+        }
+    }
+    
+    if _, err := stmt.Exec(); err != nil {
+        stmt.Close()
+        return err
+    }
+    if err := stmt.Close(); err != nil {
+        return err
+    }
+    
+    rollups := hourlyRollups(events)
+    codes := make([]string, 0, len(rollups))
+    hours := make([]string, 0, len(rollups))
+    counts := make([]int64, 0, len(rollups))
+    botCounts := make([]int64, 0, len(rollups))
+    for key, n := range rollups {
+        codes = append(codes, key.shortCode)
+        hours = append(hours, key.hour.Format("2006-01-02 15:04:05"))
+        counts = append(counts, n.Human)
+        botCounts = append(botCounts, n.Bot)
+    }
+    
+    _, err = tx.Exec(`
+        INSERT INTO click_rollups (short_code, bucket_start, clicks, bot_clicks)
+        SELECT * FROM unnest($1::text[], $2::timestamp[], $3::bigint[], $4::bigint[])
+        ON CONFLICT (short_code, bucket_start)
+        DO UPDATE SET clicks = click_rollups.clicks + EXCLUDED.clicks,
+            bot_clicks = click_rollups.bot_clicks + EXCLUDED.bot_clicks
+    `, pq.Array(codes), pq.Array(hours), pq.Array(counts), pq.Array(botCounts))
+    if err != nil {
+        return err
+    }
+    
+    return tx.Commit()
+}
+
+func (s *PostgresStore) ClickCountsByMinute(shortCode string, filter ClickFilter) ([]models.ClickBucket, error) {
+    query := `
+        SELECT date_trunc('minute', clicked_at) AS bucket, COUNT(*)
+        FROM clicks
+        WHERE short_code = $1 AND clicked_at >= $2 AND clicked_at < $3 ` + filter.botCondition() + `
+        GROUP BY bucket
+        ORDER BY bucket
+    `
+    return s.queryClickBuckets(query, shortCode, filter.From.UTC(), filter.To.UTC())
+}
+
+func (s *PostgresStore) ClickCountsByHour(shortCode string, filter ClickFilter) ([]models.ClickBucket, error) {
+    query := `
+        SELECT bucket_start, ` + filter.rollupColumn() + `
+        FROM click_rollups
+        WHERE short_code = $1 AND bucket_start >= $2 AND bucket_start < $3
+        ORDER BY bucket_start
+    `
+    return s.queryClickBuckets(query, shortCode, filter.From.UTC(), filter.To.UTC())
+}
+
+func (s *PostgresStore) ClickBreakdown(shortCode, dimension string, filter ClickFilter, limit int) ([]models.BreakdownEntry, error) {
+    query, err := breakdownQuery(dimension, filter)
+    if err != nil {
+        return nil, err
+    }
+    
+    rows, err := s.db.Query(query, shortCode, filter.From.UTC(), filter.To.UTC(), limit)
+    if err != nil {
+        return nil, err
+    }
+    return scanBreakdown(rows)
+}
+
+func (s *PostgresStore) queryClickBuckets(query string, args ...interface{}) ([]models.ClickBucket, error) {
+    rows, err := s.db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    
+    var buckets []models.ClickBucket
+    for rows.Next() {
+        var bucket models.ClickBucket
+        if err := rows.Scan(&bucket.Start, &bucket.Clicks); err != nil {
+            return nil, err
+        }
+        bucket.Start = bucket.Start.UTC()
+        buckets = append(buckets, bucket)
+    }
+    
+    return buckets, rows.Err()
+}
@@ -1,61 +1,54 @@
 package storage
 
 import (
-    "encoding/json"
-    "fmt"
     "time"
     
+    "github.com/go-redis/redis/v8"
+    
     "github.com/heydeepakch/url-shortner-golang/database"
-    "github.com/heydeepakch/url-shortner-golang/models"
 )	
 
-const urlCacheTTL = 24 * time.Hour
+// incrScript increments a counter and sets its expiry only if it has none,
+// so repeated increments don't keep pushing the expiry out
+var incrScript = redis.NewScript(`
+local value = redis.call("INCRBY", KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call("PTTL", KEYS[1]) == -1 then
+    redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return value
+`)
 
-// CacheURL stores URL in Redis cache
-func CacheURL(url *models.URL) error {
-    key := fmt.Sprintf("url:%s", url.ShortCode)
-    
-    data, err := json.Marshal(url)
-    if err != nil {
-        return err
-    }
-    
-    return database.RedisClient.Set(database.Ctx, key, data, urlCacheTTL).Err()
+// RedisCache implements Cache on a Redis client
+type RedisCache struct {
+    client *redis.Client
 }
 
-// GetCachedURL retrieves URL from Redis cache
-func GetCachedURL(shortCode string) (*models.URL, error) {
-    key := fmt.Sprintf("url:%s", shortCode)
-    
-    data, err := database.RedisClient.Get(database.Ctx, key).Bytes()
-    if err != nil {
-        return nil, err // Returns redis.Nil if not found
-    }
-    
-    var url models.URL
-    if err := json.Unmarshal(data, &url); err != nil {
-        return nil, err
+func NewRedisCache(client *redis.Client) *RedisCache {
+    return &RedisCache{client: client}
+}
+
+func (c *RedisCache) Get(key string) ([]byte, error) {
+    data, err := c.client.Get(database.Ctx, key).Bytes()
+    if err == redis.Nil {
+        return nil, ErrCacheMiss
     }
-    
-    return &url, nil
+    return data, err
 }
 
-// DeleteCachedURL removes URL from cache
-func DeleteCachedURL(shortCode string) error {
-    key := fmt.Sprintf("url:%s", shortCode)
-    return database.RedisClient.Del(database.Ctx, key).Err()
+func (c *RedisCache) Set(key string, value []byte, ttl time.Duration) error {
+    return c.client.Set(database.Ctx, key, value, ttl).Err()
 }
 
-// IncrementClicksCache increments clicks in cache
-func IncrementClicksCache(shortCode string) error {
-    // key := fmt.Sprintf("url:%s", shortCode)
-    
-    
-    url, err := GetCachedURL(shortCode)
-    if err != nil {
-        return err
-    }
-    
-    url.Clicks++
-    return CacheURL(url)
+func (c *RedisCache) Delete(key string) error {
+    return c.client.Del(database.Ctx, key).Err()
+}
+
+func (c *RedisCache) Incr(key string, delta int64, ttl time.Duration) (int64, error) {
+    return incrScript.Run(
+        database.Ctx,
+        c.client,
+        []string{key},
+        delta,
+        ttl.Milliseconds(),
+    ).Int64()
 }
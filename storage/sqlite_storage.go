@@ -0,0 +1,542 @@
+package storage
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+// SQLiteStore implements URLStore and UserStore on a single SQLite file.
+// Timestamps are always written in UTC so that the text comparisons
+// SQLite does on them order correctly.
+type SQLiteStore struct {
+    db *sql.DB
+}
+
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+    return &SQLiteStore{db: db}
+}
+
+
+func (s *SQLiteStore) CreateUser(user *models.User) error {
+    query := `
+        INSERT INTO users (username, email, password_hash, created_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `
+
+    user.CreatedAt = time.Now().UTC()
+    return s.db.QueryRow(
+        query,
+        user.Username,
+        user.Email,
+        user.PasswordHash,
+        user.CreatedAt,
+    ).Scan(&user.ID)
+}
+
+
+func (s *SQLiteStore) GetUserByEmail(email string) (*models.User, error) {
+    query := `
+        SELECT id, username, email, password_hash, created_at
+        FROM users
+        WHERE email = $1
+    `
+
+    user := &models.User{}
+    err := s.db.QueryRow(query, email).Scan(
+        &user.ID,
+        &user.Username,
+        &user.Email,
+        &user.PasswordHash,
+        &user.CreatedAt,
+    )
+
+    if err == sql.ErrNoRows {
+        return nil, errors.New("user not found")
+    }
+
+    return user, err
+}
+
+
+func (s *SQLiteStore) GetUserByID(id int) (*models.User, error) {
+    query := `
+        SELECT id, username, email, password_hash, created_at
+        FROM users
+        WHERE id = $1
+    `
+
+    user := &models.User{}
+    err := s.db.QueryRow(query, id).Scan(
+        &user.ID,
+        &user.Username,
+        &user.Email,
+        &user.PasswordHash,
+        &user.CreatedAt,
+    )
+
+    if err == sql.ErrNoRows {
+        return nil, errors.New("user not found")
+    }
+
+    return user, err
+}
+
+
+func (s *SQLiteStore) CreateURL(url *models.URL) error {
+    // Set before caching so the cached copy carries it too
+    url.CreatedAt = time.Now()
+
+    targeting, err := encodeTargeting(url.Targeting)
+    if err != nil {
+        return err
+    }
+
+    geoTargeting, err := encodeGeoTargeting(url.GeoTargeting)
+    if err != nil {
+        return err
+    }
+
+    variants, err := encodeVariants(url.Variants)
+    if err != nil {
+        return err
+    }
+
+    deepLink, err := encodeDeepLink(url.DeepLink)
+    if err != nil {
+        return err
+    }
+
+    query := `
+        INSERT INTO urls (
+            short_code, original_url, user_id, status, clicks, max_clicks,
+            created_at, starts_at, expires_at, password_hash, redirect_status,
+            title, targeting, geo_targeting, variants, link_type, deep_link
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+        RETURNING id
+    `
+
+    return s.db.QueryRow(
+        query,
+        url.ShortCode,
+        url.OriginalURL,
+        url.UserID,
+        url.Status,
+        0,
+        url.MaxClicks,
+        url.CreatedAt.UTC(),
+        utcTime(url.StartsAt),
+        utcTime(url.ExpiresAt),
+        nullString(url.PasswordHash),
+        url.RedirectStatus,
+        nullString(url.Title),
+        targeting,
+        geoTargeting,
+        variants,
+        url.Type,
+        deepLink,
+    ).Scan(&url.ID)
+}
+
+// GetURLByShortCode mirrors the Postgres filter, with the current time
+// passed in since SQLite has no NOW()
+func (s *SQLiteStore) GetURLByShortCode(shortCode string) (*models.URL, error) {
+    query := `
+        SELECT ` + urlColumns + `
+        FROM urls
+        WHERE short_code = $1
+        AND deleted_at IS NULL
+        AND status = 'active'
+        AND (starts_at IS NULL OR starts_at <= $2)
+        AND (expires_at IS NULL OR expires_at > $2)
+    `
+
+    now := time.Now().UTC()
+    url, err := scanURL(s.db.QueryRow(query, shortCode, now))
+
+    if err == sql.ErrNoRows {
+        return s.unavailable(shortCode, now)
+    }
+
+    return url, err
+}
+
+// unavailable looks up a link the live filter skipped to report why
+func (s *SQLiteStore) unavailable(shortCode string, now time.Time) (*models.URL, error) {
+    url, err := s.GetURL(shortCode)
+    if err == ErrNotFound {
+        var archived bool
+        query := `SELECT EXISTS(SELECT 1 FROM urls_archive WHERE short_code = $1)`
+        if err := s.db.QueryRow(query, shortCode).Scan(&archived); err == nil && archived {
+            return nil, ErrExpired
+        }
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if err := CheckAvailable(url, now); err != nil {
+        return nil, err
+    }
+    return url, nil
+}
+
+func (s *SQLiteStore) GetURL(shortCode string) (*models.URL, error) {
+    query := `
+        SELECT ` + urlColumns + `
+        FROM urls
+        WHERE short_code = $1
+    `
+
+    url, err := scanURL(s.db.QueryRow(query, shortCode))
+
+    if err == sql.ErrNoRows {
+        return nil, ErrNotFound
+    }
+
+    return url, err
+}
+
+func (s *SQLiteStore) UpdateURL(url *models.URL) error {
+    targeting, err := encodeTargeting(url.Targeting)
+    if err != nil {
+        return err
+    }
+
+    geoTargeting, err := encodeGeoTargeting(url.GeoTargeting)
+    if err != nil {
+        return err
+    }
+
+    variants, err := encodeVariants(url.Variants)
+    if err != nil {
+        return err
+    }
+
+    deepLink, err := encodeDeepLink(url.DeepLink)
+    if err != nil {
+        return err
+    }
+
+    query := `
+        UPDATE urls SET
+            original_url = $1, starts_at = $2, expires_at = $3,
+            status = $4, max_clicks = $5, password_hash = $6,
+            redirect_status = $7, title = $8, targeting = $9,
+            geo_targeting = $10, variants = $11, link_type = $12,
+            deep_link = $13, version = version + 1
+        WHERE id = $14 AND version = $15
+    `
+
+    result, err := s.db.Exec(
+        query,
+        url.OriginalURL,
+        utcTime(url.StartsAt),
+        utcTime(url.ExpiresAt),
+        url.Status,
+        url.MaxClicks,
+        nullString(url.PasswordHash),
+        url.RedirectStatus,
+        nullString(url.Title),
+        targeting,
+        geoTargeting,
+        variants,
+        url.Type,
+        deepLink,
+        url.ID,
+        url.Version,
+    )
+    if err != nil {
+        return err
+    }
+
+    n, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if n == 0 {
+        return ErrConflict
+    }
+
+    url.Version++
+    return nil
+}
+
+// ConsumeRedirect checks and spends the budget in one statement; SQLite
+// serialises writers so concurrent callers can't both take the last click
+func (s *SQLiteStore) ConsumeRedirect(shortCode string) (bool, error) {
+    query := `
+        UPDATE urls SET redirect_count = redirect_count + 1
+        WHERE short_code = $1
+        AND (max_clicks IS NULL OR redirect_count < max_clicks)
+    `
+    
+    result, err := s.db.Exec(query, shortCode)
+    if err != nil {
+        return false, err
+    }
+    
+    n, err := result.RowsAffected()
+    return n == 1, err
+}
+
+func (s *SQLiteStore) DeleteURL(shortCode string, at time.Time) error {
+    query := `UPDATE urls SET deleted_at = $1 WHERE short_code = $2`
+    _, err := s.db.Exec(query, at.UTC(), shortCode)
+    return err
+}
+
+func (s *SQLiteStore) RestoreURL(shortCode string) error {
+    query := `UPDATE urls SET deleted_at = NULL WHERE short_code = $1`
+    _, err := s.db.Exec(query, shortCode)
+    return err
+}
+
+// AddClicks applies the batch in one transaction so it costs a single fsync
+func (s *SQLiteStore) AddClicks(counts map[string]ClickCounts) error {
+    if len(counts) == 0 {
+        return nil
+    }
+
+    tx, err := s.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    stmt, err := tx.Prepare(`
+        UPDATE urls SET clicks = clicks + $1, bot_clicks = bot_clicks + $2
+        WHERE short_code = $3
+    `)
+    if err != nil {
+        return err
+    }
+    defer stmt.Close()
+
+    for code, n := range counts {
+        if _, err := stmt.Exec(n.Human, n.Bot, code); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
+}
+
+func (s *SQLiteStore) GetUserURLs(userID int) ([]models.URL, error) {
+    query := `
+        SELECT ` + urlColumns + `
+        FROM urls
+        WHERE user_id = $1
+        ORDER BY created_at DESC
+    `
+
+    rows, err := s.db.Query(query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var urls []models.URL
+    for rows.Next() {
+        url, err := scanURL(rows)
+        if err != nil {
+            return nil, err
+        }
+        urls = append(urls, *url)
+    }
+
+    return urls, rows.Err()
+}
+
+func (s *SQLiteStore) ShortCodeExists(shortCode string) (bool, error) {
+    query := `
+        SELECT EXISTS(SELECT 1 FROM urls WHERE short_code = $1)
+        OR EXISTS(SELECT 1 FROM urls_archive WHERE short_code = $1)
+        OR EXISTS(SELECT 1 FROM reserved_codes WHERE short_code = $1)
+    `
+    var exists bool
+    err := s.db.QueryRow(query, shortCode).Scan(&exists)
+    return exists, err
+}
+
+func (s *SQLiteStore) ReapExpiredURLs(expiredBefore time.Time, archive bool) (int64, error) {
+    tx, err := s.db.Begin()
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    cutoff := expiredBefore.UTC()
+
+    if archive {
+        _, err := tx.Exec(`
+            INSERT INTO urls_archive (
+                id, short_code, original_url, user_id, clicks, bot_clicks, created_at, expires_at, archived_at
+            )
+            SELECT id, short_code, original_url, user_id, clicks, bot_clicks, created_at, expires_at, $2
+            FROM urls WHERE expires_at < $1
+        `, cutoff, time.Now().UTC())
+        if err != nil {
+            return 0, err
+        }
+    } else {
+        // Purged links keep only their code so it is never reissued
+        _, err := tx.Exec(`
+            INSERT OR IGNORE INTO reserved_codes (short_code, reserved_at)
+            SELECT short_code, $2 FROM urls WHERE expires_at < $1
+        `, cutoff, time.Now().UTC())
+        if err != nil {
+            return 0, err
+        }
+    }
+
+    result, err := tx.Exec(`DELETE FROM urls WHERE expires_at < $1`, cutoff)
+    if err != nil {
+        return 0, err
+    }
+
+    n, err := result.RowsAffected()
+    if err != nil {
+        return 0, err
+    }
+    return n, tx.Commit()
+}
+
+func (s *SQLiteStore) InsertClickEvents(events []models.ClickEvent) error {
+    if len(events) == 0 {
+        return nil
+    }
+
+    tx, err := s.db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    stmt, err := tx.Prepare(`
+        INSERT INTO clicks (
+            short_code, clicked_at, referrer, user_agent, ip_hash, accept_language, is_bot,
+            referrer_domain, browser, os, device, country, region, city, variant
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+    `)
+    if err != nil {
+        return err
+    }
+    defer stmt.Close()
+
+    for _, event := range events {
+        if _, err := stmt.Exec(
+            event.ShortCode,
+            event.ClickedAt.UTC(),
+            event.Referrer,
+            event.UserAgent,
+            event.IPHash,
+            event.AcceptLanguage,
+            event.IsBot,
+            event.ReferrerDomain,
+            event.Browser,
+            event.OS,
+            event.Device,
+            event.Country,
+            event.Region,
+            event.City,
+            nullString(event.Variant),
+        ); err != nil {
+            return err
+        }
+    }
+
+    for key, n := range hourlyRollups(events) {
+        if _, err := tx.Exec(`
+            INSERT INTO click_rollups (short_code, bucket_start, clicks, bot_clicks)
+            VALUES ($1, $2, $3, $4)
+            ON CONFLICT (short_code, bucket_start)
+            DO UPDATE SET clicks = clicks + excluded.clicks,
+                bot_clicks = bot_clicks + excluded.bot_clicks
+        `, key.shortCode, key.hour, n.Human, n.Bot); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
+}
+
+// ClickCountsByMinute groups on the "YYYY-MM-DD HH:MM" prefix of the
+// stored UTC timestamp
+func (s *SQLiteStore) ClickCountsByMinute(shortCode string, filter ClickFilter) ([]models.ClickBucket, error) {
+    query := `
+        SELECT substr(clicked_at, 1, 16) AS bucket, COUNT(*)
+        FROM clicks
+        WHERE short_code = $1 AND clicked_at >= $2 AND clicked_at < $3 ` + filter.botCondition() + `
+        GROUP BY bucket
+        ORDER BY bucket
+    `
+
+    rows, err := s.db.Query(query, shortCode, filter.From.UTC(), filter.To.UTC())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var buckets []models.ClickBucket
+    for rows.Next() {
+        var minute string
+        var bucket models.ClickBucket
+        if err := rows.Scan(&minute, &bucket.Clicks); err != nil {
+            return nil, err
+        }
+
+        bucket.Start, err = time.Parse("2006-01-02 15:04", minute)
+        if err != nil {
+            return nil, err
+        }
+        buckets = append(buckets, bucket)
+    }
+
+    return buckets, rows.Err()
+}
+
+func (s *SQLiteStore) ClickCountsByHour(shortCode string, filter ClickFilter) ([]models.ClickBucket, error) {
+    query := `
+        SELECT bucket_start, ` + filter.rollupColumn() + `
+        FROM click_rollups
+        WHERE short_code = $1 AND bucket_start >= $2 AND bucket_start < $3
+        ORDER BY bucket_start
+    `
+
+    rows, err := s.db.Query(query, shortCode, filter.From.UTC(), filter.To.UTC())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var buckets []models.ClickBucket
+    for rows.Next() {
+        var bucket models.ClickBucket
+        if err := rows.Scan(&bucket.Start, &bucket.Clicks); err != nil {
+            return nil, err
+        }
+        bucket.Start = bucket.Start.UTC()
+        buckets = append(buckets, bucket)
+    }
+
+    return buckets, rows.Err()
+}
+
+func (s *SQLiteStore) ClickBreakdown(shortCode, dimension string, filter ClickFilter, limit int) ([]models.BreakdownEntry, error) {
+    query, err := breakdownQuery(dimension, filter)
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err := s.db.Query(query, shortCode, filter.From.UTC(), filter.To.UTC(), limit)
+    if err != nil {
+        return nil, err
+    }
+    return scanBreakdown(rows)
+}
@@ -0,0 +1,340 @@
+package storage
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "time"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+// Reasons a short code can't be followed, returned by URLStore lookups
+var (
+    ErrNotFound = errors.New("URL not found")
+    ErrExpired  = errors.New("URL has expired")
+    ErrDeleted  = errors.New("URL has been deleted")
+    ErrPaused   = errors.New("URL is paused")
+)
+
+// ErrConflict is returned by UpdateURL when the link changed after it
+// was loaded
+var ErrConflict = errors.New("URL was modified concurrently")
+
+// NotYetActiveError is returned for a link whose starts_at is still ahead
+type NotYetActiveError struct {
+    StartsAt time.Time
+}
+
+func (e *NotYetActiveError) Error() string {
+    return "URL is not active until " + e.StartsAt.Format(time.RFC3339)
+}
+
+// URLStore persists short links. Deleted links are kept as tombstones so
+// that ShortCodeExists keeps reporting their codes as taken.
+type URLStore interface {
+    CreateURL(url *models.URL) error
+    // GetURLByShortCode returns a live link, or ErrNotFound, ErrExpired,
+    // ErrDeleted, ErrPaused or *NotYetActiveError saying why there is none.
+    // Archived links count as expired.
+    GetURLByShortCode(shortCode string) (*models.URL, error)
+    // GetURL looks a link up regardless of expiry or deletion, for its owner
+    GetURL(shortCode string) (*models.URL, error)
+    // UpdateURL saves the mutable fields of an existing link: destination,
+    // schedule, status, click limit and password. It returns ErrConflict
+    // if the link was updated since url was loaded, and otherwise bumps
+    // url.Version.
+    UpdateURL(url *models.URL) error
+    // ConsumeRedirect atomically spends one redirect of a click-limited
+    // link, reporting false once its max_clicks budget is used up
+    ConsumeRedirect(shortCode string) (bool, error)
+    // DeleteURL marks a link deleted at the given time
+    DeleteURL(shortCode string, at time.Time) error
+    // RestoreURL clears a link's deletion
+    RestoreURL(shortCode string) error
+    // AddClicks adds a batch of click counts keyed by short code
+    AddClicks(counts map[string]ClickCounts) error
+    GetUserURLs(userID int) ([]models.URL, error)
+    // ShortCodeExists also counts archived and purged codes as taken
+    ShortCodeExists(shortCode string) (bool, error)
+    // ReapExpiredURLs removes links that expired before the cutoff, moving
+    // them to the archive when archive is set and otherwise keeping only
+    // their codes reserved, and returns how many
+    ReapExpiredURLs(expiredBefore time.Time, archive bool) (int64, error)
+}
+
+// UserStore persists user accounts
+type UserStore interface {
+    CreateUser(user *models.User) error
+    GetUserByEmail(email string) (*models.User, error)
+    GetUserByID(id int) (*models.User, error)
+}
+
+// ClickStore persists per-click analytics events
+type ClickStore interface {
+    // InsertClickEvents stores the events and adds them to the hourly rollups
+    InsertClickEvents(events []models.ClickEvent) error
+    // ClickCountsByMinute counts raw events per UTC minute
+    ClickCountsByMinute(shortCode string, filter ClickFilter) ([]models.ClickBucket, error)
+    // ClickCountsByHour reads the hourly rollups
+    ClickCountsByHour(shortCode string, filter ClickFilter) ([]models.ClickBucket, error)
+    // ClickBreakdown returns the top limit values of dimension
+    ClickBreakdown(shortCode, dimension string, filter ClickFilter, limit int) ([]models.BreakdownEntry, error)
+}
+
+// ClickCounts splits clicks into human and bot traffic
+type ClickCounts struct {
+    Human int64
+    Bot   int64
+}
+
+// ClickFilter selects the click events a stats query covers: those in
+// [From, To), leaving out bot traffic unless IncludeBots is set
+type ClickFilter struct {
+    From        time.Time
+    To          time.Time
+    IncludeBots bool
+}
+
+// matches reports whether an in-memory event passes the filter
+func (f ClickFilter) matches(event models.ClickEvent) bool {
+    return (f.IncludeBots || !event.IsBot) &&
+        !event.ClickedAt.Before(f.From) && event.ClickedAt.Before(f.To)
+}
+
+// botCondition is the extra clicks table predicate for the filter
+func (f ClickFilter) botCondition() string {
+    if f.IncludeBots {
+        return ""
+    }
+    return "AND NOT is_bot"
+}
+
+// rollupColumn is the click_rollups expression counted for the filter
+func (f ClickFilter) rollupColumn() string {
+    if f.IncludeBots {
+        return "clicks + bot_clicks"
+    }
+    return "clicks"
+}
+
+// urlColumns is the column list scanned by scanURL
+const urlColumns = `id, short_code, original_url, user_id, status, clicks, bot_clicks, max_clicks, redirect_count, created_at, starts_at, expires_at, deleted_at, password_hash, redirect_status, title, targeting, geo_targeting, variants, link_type, deep_link, version`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+    Scan(dest ...interface{}) error
+}
+
+// scanURL reads one row selected with urlColumns
+func scanURL(row rowScanner) (*models.URL, error) {
+    url := &models.URL{}
+    var passwordHash, title, targeting, geoTargeting, variants, deepLink sql.NullString
+    err := row.Scan(
+        &url.ID,
+        &url.ShortCode,
+        &url.OriginalURL,
+        &url.UserID,
+        &url.Status,
+        &url.Clicks,
+        &url.BotClicks,
+        &url.MaxClicks,
+        &url.RedirectCount,
+        &url.CreatedAt,
+        &url.StartsAt,
+        &url.ExpiresAt,
+        &url.DeletedAt,
+        &passwordHash,
+        &url.RedirectStatus,
+        &title,
+        &targeting,
+        &geoTargeting,
+        &variants,
+        &url.Type,
+        &deepLink,
+        &url.Version,
+    )
+    if err != nil {
+        return url, err
+    }
+    
+    url.PasswordHash = passwordHash.String
+    url.Title = title.String
+    
+    if targeting.Valid {
+        err = json.Unmarshal([]byte(targeting.String), &url.Targeting)
+    }
+    if err == nil && geoTargeting.Valid {
+        err = json.Unmarshal([]byte(geoTargeting.String), &url.GeoTargeting)
+    }
+    if err == nil && variants.Valid {
+        err = json.Unmarshal([]byte(variants.String), &url.Variants)
+    }
+    if err == nil && deepLink.Valid {
+        err = json.Unmarshal([]byte(deepLink.String), &url.DeepLink)
+    }
+    
+    return url, err
+}
+
+// encodeTargeting stores targeting rules as JSON, or NULL when there are none
+func encodeTargeting(rules []models.TargetingRule) (sql.NullString, error) {
+    if len(rules) == 0 {
+        return sql.NullString{}, nil
+    }
+    data, err := json.Marshal(rules)
+    return nullString(string(data)), err
+}
+
+// encodeGeoTargeting stores country destinations as JSON, or NULL when
+// there are none
+func encodeGeoTargeting(destinations map[string]string) (sql.NullString, error) {
+    if len(destinations) == 0 {
+        return sql.NullString{}, nil
+    }
+    data, err := json.Marshal(destinations)
+    return nullString(string(data)), err
+}
+
+// encodeVariants stores A/B destinations as JSON, or NULL when there are none
+func encodeVariants(variants []models.Variant) (sql.NullString, error) {
+    if len(variants) == 0 {
+        return sql.NullString{}, nil
+    }
+    data, err := json.Marshal(variants)
+    return nullString(string(data)), err
+}
+
+// encodeDeepLink stores app targets as JSON, or NULL for ordinary links
+func encodeDeepLink(deepLink *models.DeepLink) (sql.NullString, error) {
+    if deepLink == nil {
+        return sql.NullString{}, nil
+    }
+    data, err := json.Marshal(deepLink)
+    return nullString(string(data)), err
+}
+
+// utcTime normalises an optional timestamp to UTC before it is stored.
+// The timestamp columns carry no zone, so an offset would be dropped and
+// the instant shifted.
+func utcTime(t *time.Time) *time.Time {
+    if t == nil {
+        return nil
+    }
+    
+    utc := t.UTC()
+    return &utc
+}
+
+// nullString stores an empty string as NULL
+func nullString(s string) sql.NullString {
+    return sql.NullString{String: s, Valid: s != ""}
+}
+
+// CheckAvailable explains why a link can't be followed at now, or
+// returns nil if it can
+func CheckAvailable(url *models.URL, now time.Time) error {
+    switch {
+    case url.DeletedAt != nil:
+        return ErrDeleted
+    case url.ExpiresAt != nil && !url.ExpiresAt.After(now):
+        return ErrExpired
+    case url.StartsAt != nil && url.StartsAt.After(now):
+        return &NotYetActiveError{StartsAt: *url.StartsAt}
+    case url.Status == models.URLStatusPaused:
+        return ErrPaused
+    }
+    return nil
+}
+
+// breakdownColumns maps breakdown dimensions to their clicks table column.
+// Only these names are ever interpolated into SQL.
+var breakdownColumns = map[string]string{
+    "referrer": "referrer_domain",
+    "browser":  "browser",
+    "os":       "os",
+    "device":   "device",
+    "country":  "country",
+    "region":   "region",
+    "city":     "city",
+    "variant":  "variant",
+}
+
+// breakdownValue reads a breakdown dimension from an in-memory event
+func breakdownValue(event models.ClickEvent, dimension string) string {
+    switch dimension {
+    case "referrer":
+        return event.ReferrerDomain
+    case "browser":
+        return event.Browser
+    case "os":
+        return event.OS
+    case "country":
+        return event.Country
+    case "region":
+        return event.Region
+    case "city":
+        return event.City
+    case "variant":
+        return event.Variant
+    default:
+        return event.Device
+    }
+}
+
+// hourlyRollups groups events by short code and UTC hour
+func hourlyRollups(events []models.ClickEvent) map[rollupKey]ClickCounts {
+    rollups := make(map[rollupKey]ClickCounts)
+    for _, event := range events {
+        key := rollupKey{
+            shortCode: event.ShortCode,
+            hour:      event.ClickedAt.UTC().Truncate(time.Hour),
+        }
+        counts := rollups[key]
+        if event.IsBot {
+            counts.Bot++
+        } else {
+            counts.Human++
+        }
+        rollups[key] = counts
+    }
+    return rollups
+}
+
+type rollupKey struct {
+    shortCode string
+    hour      time.Time
+}
+
+// breakdownQuery builds the grouped count query for dimension
+func breakdownQuery(dimension string, filter ClickFilter) (string, error) {
+    column, ok := breakdownColumns[dimension]
+    if !ok {
+        return "", fmt.Errorf("unknown breakdown dimension %q", dimension)
+    }
+    
+    return fmt.Sprintf(`
+        SELECT COALESCE(%s, ''), COUNT(*)
+        FROM clicks
+        WHERE short_code = $1 AND clicked_at >= $2 AND clicked_at < $3 %s
+        GROUP BY 1
+        ORDER BY 2 DESC, 1
+        LIMIT $4
+    `, column, filter.botCondition()), nil
+}
+
+func scanBreakdown(rows *sql.Rows) ([]models.BreakdownEntry, error) {
+    defer rows.Close()
+    
+    var entries []models.BreakdownEntry
+    for rows.Next() {
+        var entry models.BreakdownEntry
+        if err := rows.Scan(&entry.Value, &entry.Clicks); err != nil {
+            return nil, err
+        }
+        entries = append(entries, entry)
+    }
+    
+    return entries, rows.Err()
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+    "encoding/json"
+    "fmt"
+    "strconv"
+    "time"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+const urlCacheTTL = 24 * time.Hour
+
+// clickCounterTTL bounds how long a shared pending counter can outlive the
+// instance that incremented it, e.g. after a crash before its last flush
+const clickCounterTTL = 24 * time.Hour
+
+// cachedURL is the cache encoding of a link. It carries the fields the
+// redirect path needs that API responses hide.
+type cachedURL struct {
+    models.URL
+    PasswordHash string `json:"password_hash,omitempty"`
+}
+
+// URLCache caches resolved links in a Cache
+type URLCache struct {
+    cache Cache
+}
+
+func NewURLCache(cache Cache) *URLCache {
+    return &URLCache{cache: cache}
+}
+
+// CacheURL stores URL in cache. The cached record only carries redirect
+// metadata; click counts live in their own counter key. Entries never
+// outlive the link's expiry.
+func (c *URLCache) CacheURL(url *models.URL) error {
+    key := fmt.Sprintf("url:%s", url.ShortCode)
+    
+    ttl := urlCacheTTL
+    if url.ExpiresAt != nil {
+        remaining := time.Until(*url.ExpiresAt)
+        if remaining <= 0 {
+            return nil
+        }
+        if remaining < ttl {
+            ttl = remaining
+        }
+    }
+    
+    cached := cachedURL{URL: *url, PasswordHash: url.PasswordHash}
+    cached.Clicks = 0
+    cached.BotClicks = 0
+    
+    data, err := json.Marshal(cached)
+    if err != nil {
+        return err
+    }
+    
+    return c.cache.Set(key, data, ttl)
+}
+
+// GetCachedURL retrieves URL from cache. An entry whose link has expired
+// since it was cached is dropped and reported as a miss.
+func (c *URLCache) GetCachedURL(shortCode string) (*models.URL, error) {
+    key := fmt.Sprintf("url:%s", shortCode)
+    
+    data, err := c.cache.Get(key)
+    if err != nil {
+        return nil, err // Returns ErrCacheMiss if not found
+    }
+    
+    var cached cachedURL
+    if err := json.Unmarshal(data, &cached); err != nil {
+        return nil, err
+    }
+    
+    url := cached.URL
+    url.PasswordHash = cached.PasswordHash
+    
+    if url.ExpiresAt != nil && !url.ExpiresAt.After(time.Now()) {
+        c.cache.Delete(key)
+        return nil, ErrCacheMiss
+    }
+    
+    return &url, nil
+}
+
+// DeleteCachedURL removes URL from cache
+func (c *URLCache) DeleteCachedURL(shortCode string) error {
+    key := fmt.Sprintf("url:%s", shortCode)
+    return c.cache.Delete(key)
+}
+
+// pendingKey names the shared counter for human or bot clicks
+func pendingKey(shortCode string, bot bool) string {
+    if bot {
+        return fmt.Sprintf("botclicks:%s", shortCode)
+    }
+    return fmt.Sprintf("clicks:%s", shortCode)
+}
+
+// AddPasswordAttempt counts one password attempt on shortCode by client
+// and returns how many it has made within window
+func (c *URLCache) AddPasswordAttempt(shortCode, client string, window time.Duration) (int64, error) {
+    key := fmt.Sprintf("pwattempts:%s:%s", shortCode, client)
+    return c.cache.Incr(key, 1, window)
+}
+
+// AddPendingClicks atomically adjusts the shared count of clicks that have
+// been recorded by any instance but not yet written to the database.
+// A counter that expired, or whose increments went to the fallback cache
+// during an outage, would go negative on the matching decrement; the
+// deficit is added back so it settles at zero.
+func (c *URLCache) AddPendingClicks(shortCode string, bot bool, delta int64) (int64, error) {
+    key := pendingKey(shortCode, bot)
+    n, err := c.cache.Incr(key, delta, clickCounterTTL)
+    if err != nil || n >= 0 {
+        return n, err
+    }
+    return c.cache.Incr(key, -n, clickCounterTTL)
+}
+
+// PendingClicks returns the shared pending click count, or 0 if unknown
+func (c *URLCache) PendingClicks(shortCode string, bot bool) (int64, error) {
+    data, err := c.cache.Get(pendingKey(shortCode, bot))
+    if err != nil {
+        return 0, err
+    }
+    
+    n, err := strconv.ParseInt(string(data), 10, 64)
+    if err != nil || n < 0 {
+        return 0, err
+    }
+    
+    return n, nil
+}
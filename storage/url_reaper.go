@@ -0,0 +1,64 @@
+package storage
+
+import (
+    "log"
+    "time"
+)
+
+// URLReaper periodically removes links that have been expired for longer
+// than a grace period, archiving them or purging them outright. Purged
+// links keep only their code, which stays reserved and is never issued
+// again.
+type URLReaper struct {
+    store   URLStore
+    grace   time.Duration
+    archive bool
+
+    stop chan struct{}
+    done chan struct{}
+}
+
+func NewURLReaper(store URLStore, grace time.Duration, archive bool) *URLReaper {
+    return &URLReaper{store: store, grace: grace, archive: archive}
+}
+
+// Reap removes every link that expired more than the grace period ago
+func (r *URLReaper) Reap() (int64, error) {
+    return r.store.ReapExpiredURLs(time.Now().Add(-r.grace), r.archive)
+}
+
+// Start reaps immediately and then every interval until Stop is called
+func (r *URLReaper) Start(interval time.Duration) {
+    r.stop = make(chan struct{})
+    r.done = make(chan struct{})
+
+    go func() {
+        defer close(r.done)
+
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+
+        for {
+            n, err := r.Reap()
+            if err != nil {
+                log.Println("Failed to reap expired URLs:", err)
+            } else if n > 0 {
+                log.Printf("Reaped %d expired URLs", n)
+            }
+
+            select {
+            case <-ticker.C:
+            case <-r.stop:
+                return
+            }
+        }
+    }()
+}
+
+// Stop ends the reaping loop
+func (r *URLReaper) Stop() {
+    if r.stop != nil {
+        close(r.stop)
+        <-r.done
+    }
+}
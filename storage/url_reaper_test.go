@@ -0,0 +1,45 @@
+package storage
+
+import (
+    "testing"
+    "time"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+func TestReapExpiredURLsKeepsCodesReserved(t *testing.T) {
+    for name, store := range testStores(t) {
+        t.Run(name, func(t *testing.T) {
+            expired := time.Now().Add(-48 * time.Hour)
+            for _, code := range []string{"archived", "purged"} {
+                if err := store.CreateURL(&models.URL{ShortCode: code, OriginalURL: "https://example.com", ExpiresAt: &expired}); err != nil {
+                    t.Fatal(err)
+                }
+            }
+            if err := store.DeleteURL("purged", time.Now()); err != nil {
+                t.Fatal(err)
+            }
+            
+            if n, err := store.ReapExpiredURLs(time.Now(), true); err != nil || n != 2 {
+                t.Fatalf("archive reaped %d, %v; want 2", n, err)
+            }
+            
+            if err := store.CreateURL(&models.URL{ShortCode: "purged2", OriginalURL: "https://example.com", ExpiresAt: &expired}); err != nil {
+                t.Fatal(err)
+            }
+            if n, err := store.ReapExpiredURLs(time.Now(), false); err != nil || n != 1 {
+                t.Fatalf("purge reaped %d, %v; want 1", n, err)
+            }
+            
+            for _, code := range []string{"archived", "purged", "purged2"} {
+                exists, err := store.ShortCodeExists(code)
+                if err != nil {
+                    t.Fatal(err)
+                }
+                if !exists {
+                    t.Errorf("ShortCodeExists(%q) = false after reaping", code)
+                }
+            }
+        })
+    }
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+    "sync"
+    "sync/atomic"
+    "testing"
+    
+    "github.com/heydeepakch/url-shortner-golang/database"
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+// testStores returns a fresh MemoryStore and SQLiteStore
+func testStores(t *testing.T) map[string]URLStore {
+    t.Helper()
+    
+    if err := database.InitSQLite(t.TempDir() + "/test.db"); err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { database.DB.Close() })
+    
+    return map[string]URLStore{
+        "memory": NewMemoryStore(),
+        "sqlite": NewSQLiteStore(database.DB),
+    }
+}
+
+func TestConsumeRedirectConcurrent(t *testing.T) {
+    for name, store := range testStores(t) {
+        t.Run(name, func(t *testing.T) {
+            maxClicks := int64(5)
+            url := &models.URL{ShortCode: "limited", OriginalURL: "https://example.com", MaxClicks: &maxClicks}
+            if err := store.CreateURL(url); err != nil {
+                t.Fatal(err)
+            }
+            
+            var granted atomic.Int64
+            var wg sync.WaitGroup
+            for i := 0; i < 50; i++ {
+                wg.Add(1)
+                go func() {
+                    defer wg.Done()
+                    ok, err := store.ConsumeRedirect("limited")
+                    if err != nil {
+                        t.Error(err)
+                        return
+                    }
+                    if ok {
+                        granted.Add(1)
+                    }
+                }()
+            }
+            wg.Wait()
+            
+            if got := granted.Load(); got != maxClicks {
+                t.Fatalf("granted %d redirects, want %d", got, maxClicks)
+            }
+            
+            stored, err := store.GetURL("limited")
+            if err != nil {
+                t.Fatal(err)
+            }
+            if stored.RedirectCount != maxClicks {
+                t.Fatalf("redirect_count = %d, want %d", stored.RedirectCount, maxClicks)
+            }
+        })
+    }
+}
+
+func TestConsumeRedirectUnlimited(t *testing.T) {
+    for name, store := range testStores(t) {
+        t.Run(name, func(t *testing.T) {
+            if err := store.CreateURL(&models.URL{ShortCode: "open", OriginalURL: "https://example.com"}); err != nil {
+                t.Fatal(err)
+            }
+            for i := 0; i < 3; i++ {
+                if ok, err := store.ConsumeRedirect("open"); err != nil || !ok {
+                    t.Fatalf("ConsumeRedirect = %v, %v; want true", ok, err)
+                }
+            }
+            if ok, _ := store.ConsumeRedirect("missing"); ok {
+                t.Fatal("ConsumeRedirect granted a redirect for a missing link")
+            }
+        })
+    }
+}
+
+func TestUpdateURLRejectsStaleVersion(t *testing.T) {
+    for name, store := range testStores(t) {
+        t.Run(name, func(t *testing.T) {
+            if err := store.CreateURL(&models.URL{ShortCode: "edited", OriginalURL: "https://example.com", Status: models.URLStatusActive}); err != nil {
+                t.Fatal(err)
+            }
+            
+            patch, _ := store.GetURL("edited")
+            pause, _ := store.GetURL("edited")
+            
+            pause.Status = models.URLStatusPaused
+            if err := store.UpdateURL(pause); err != nil {
+                t.Fatal(err)
+            }
+            
+            patch.OriginalURL = "https://example.org"
+            if err := store.UpdateURL(patch); err != ErrConflict {
+                t.Fatalf("stale UpdateURL error = %v, want ErrConflict", err)
+            }
+            
+            stored, _ := store.GetURL("edited")
+            if stored.Status != models.URLStatusPaused || stored.OriginalURL != "https://example.com" {
+                t.Fatalf("stored link = %s %s, want the pause kept", stored.Status, stored.OriginalURL)
+            }
+            
+            // Reloaded, the edit applies on top of the pause
+            stored.OriginalURL = "https://example.org"
+            if err := store.UpdateURL(stored); err != nil {
+                t.Fatal(err)
+            }
+            if again, _ := store.GetURL("edited"); again.Status != models.URLStatusPaused || again.OriginalURL != "https://example.org" {
+                t.Fatalf("stored link = %s %s after retry", again.Status, again.OriginalURL)
+            }
+        })
+    }
+}
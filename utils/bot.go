@@ -0,0 +1,53 @@
+package utils
+
+import (
+    _ "embed"
+    "net/http"
+    "strings"
+)
+
+//go:embed bot_signatures.txt
+var botSignatureFile string
+
+// botSignatures is the parsed, lower-cased signature list
+var botSignatures = parseSignatures(botSignatureFile)
+
+func parseSignatures(file string) []string {
+    var signatures []string
+    for _, line := range strings.Split(file, "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        signatures = append(signatures, strings.ToLower(line))
+    }
+    return signatures
+}
+
+// IsBotUserAgent reports whether ua matches a known automated client.
+// An empty User-Agent is treated as a bot since browsers always send one.
+func IsBotUserAgent(ua string) bool {
+    if strings.TrimSpace(ua) == "" {
+        return true
+    }
+    return containsAny(strings.ToLower(ua), botSignatures)
+}
+
+// IsBotRequest classifies a redirect request as automated using the UA
+// signature list plus request heuristics: HEAD requests (link checkers
+// and unfurlers probing the target) and speculative prefetches, which
+// browsers mark with Purpose / Sec-Purpose headers
+func IsBotRequest(r *http.Request) bool {
+    if r.Method == http.MethodHead {
+        return true
+    }
+    
+    for _, header := range []string{"Purpose", "Sec-Purpose", "X-Purpose", "X-Moz"} {
+        value := strings.ToLower(r.Header.Get(header))
+        if strings.Contains(value, "prefetch") || strings.Contains(value, "preview") {
+            return true
+        }
+    }
+    
+    return IsBotUserAgent(r.UserAgent())
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "net"
+    
+    "github.com/heydeepakch/url-shortner-golang/config"
+)
+
+// HashIP anonymises a client IP for analytics. The address is first
+// truncated to its /24 (IPv4) or /48 (IPv6) network and then keyed-hashed,
+// so the stored value can group visitors but can't be reversed to an IP.
+func HashIP(ip string) string {
+    parsed := net.ParseIP(ip)
+    if parsed == nil {
+        return ""
+    }
+    
+    var network net.IP
+    if v4 := parsed.To4(); v4 != nil {
+        network = v4.Mask(net.CIDRMask(24, 32))
+    } else {
+        network = parsed.Mask(net.CIDRMask(48, 128))
+    }
+    
+    mac := hmac.New(sha256.New, []byte(config.AppConfig.IPHashSecret))
+    mac.Write(network)
+    return hex.EncodeToString(mac.Sum(nil)[:16])
+}
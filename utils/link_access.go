@@ -0,0 +1,43 @@
+package utils
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "strconv"
+    "strings"
+    "time"
+    
+    "github.com/heydeepakch/url-shortner-golang/config"
+)
+
+// SignLinkAccess returns a cookie value that unlocks a password protected
+// link until expires. The password hash is part of the signature, so
+// changing the password revokes every cookie already handed out.
+func SignLinkAccess(shortCode, passwordHash string, expires time.Time) string {
+    expiry := strconv.FormatInt(expires.Unix(), 10)
+    return expiry + "." + linkAccessMAC(shortCode, passwordHash, expiry)
+}
+
+// VerifyLinkAccess reports whether value is an unexpired cookie issued by
+// SignLinkAccess for this link and password
+func VerifyLinkAccess(value, shortCode, passwordHash string) bool {
+    expiry, signature, ok := strings.Cut(value, ".")
+    if !ok {
+        return false
+    }
+    
+    unix, err := strconv.ParseInt(expiry, 10, 64)
+    if err != nil || time.Now().Unix() > unix {
+        return false
+    }
+    
+    expected := linkAccessMAC(shortCode, passwordHash, expiry)
+    return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+func linkAccessMAC(shortCode, passwordHash, expiry string) string {
+    mac := hmac.New(sha256.New, []byte(config.AppConfig.JWTSecret))
+    mac.Write([]byte(shortCode + "\x00" + passwordHash + "\x00" + expiry))
+    return hex.EncodeToString(mac.Sum(nil))
+}
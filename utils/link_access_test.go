@@ -0,0 +1,48 @@
+package utils
+
+import (
+    "testing"
+    "time"
+    
+    "github.com/heydeepakch/url-shortner-golang/config"
+)
+
+func TestVerifyLinkAccess(t *testing.T) {
+    config.AppConfig = &config.Config{JWTSecret: "test-secret"}
+    
+    valid := SignLinkAccess("abc123", "hash", time.Now().Add(time.Hour))
+    expired := SignLinkAccess("abc123", "hash", time.Now().Add(-time.Minute))
+    
+    tests := []struct {
+        name         string
+        value        string
+        shortCode    string
+        passwordHash string
+        want         bool
+    }{
+        {"valid", valid, "abc123", "hash", true},
+        {"expired", expired, "abc123", "hash", false},
+        {"other link", valid, "xyz789", "hash", false},
+        {"password changed", valid, "abc123", "new-hash", false},
+        {"tampered expiry", "9999999999" + valid[len(valid)-65:], "abc123", "hash", false},
+        {"no signature", "9999999999", "abc123", "hash", false},
+        {"bad expiry", "soon.abcdef", "abc123", "hash", false},
+        {"empty", "", "abc123", "hash", false},
+    }
+    
+    for _, tt := range tests {
+        if got := VerifyLinkAccess(tt.value, tt.shortCode, tt.passwordHash); got != tt.want {
+            t.Errorf("%s: VerifyLinkAccess = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestVerifyLinkAccessOtherSecret(t *testing.T) {
+    config.AppConfig = &config.Config{JWTSecret: "test-secret"}
+    value := SignLinkAccess("abc123", "hash", time.Now().Add(time.Hour))
+    
+    config.AppConfig = &config.Config{JWTSecret: "rotated-secret"}
+    if VerifyLinkAccess(value, "abc123", "hash") {
+        t.Fatal("cookie signed with the old secret was accepted")
+    }
+}
@@ -10,13 +10,13 @@ import (
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 const defaultLength = 7
 
-// GenerateShortCode generates a unique random short code
-func GenerateShortCode() (string, error) {
-    return GenerateShortCodeWithLength(defaultLength)
+// GenerateShortCode generates a random short code not yet used in store
+func GenerateShortCode(store storage.URLStore) (string, error) {
+    return GenerateShortCodeWithLength(store, defaultLength)
 }
 
 // GenerateShortCodeWithLength generates short code of specific length
-func GenerateShortCodeWithLength(length int) (string, error) {
+func GenerateShortCodeWithLength(store storage.URLStore, length int) (string, error) {
     maxAttempts := 5
     
     for attempt := 0; attempt < maxAttempts; attempt++ {
@@ -26,7 +26,7 @@ func GenerateShortCodeWithLength(length int) (string, error) {
         }
         
         // Check if code already exists
-        exists, err := storage.ShortCodeExists(code)
+        exists, err := store.ShortCodeExists(code)
         if err != nil {
             return "", err
         }
@@ -37,7 +37,7 @@ func GenerateShortCodeWithLength(length int) (string, error) {
     }
     
     // If collision after max attempts, increase length
-    return GenerateShortCodeWithLength(length + 1)
+    return GenerateShortCodeWithLength(store, length + 1)
 }
 
 // generateRandomCode creates a cryptographically secure random string
@@ -0,0 +1,101 @@
+package utils
+
+import (
+    "errors"
+    "time"
+
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+// MaxTimeSeriesBuckets limits how many buckets one request may produce
+const MaxTimeSeriesBuckets = 5000
+
+var ErrTooManyBuckets = errors.New("time range too large for granularity")
+
+// ValidGranularity reports whether g is a supported bucket size
+func ValidGranularity(g string) bool {
+    switch g {
+    case "minute", "hour", "day", "week":
+        return true
+    }
+    return false
+}
+
+// BucketStart truncates t to the start of its bucket in loc.
+// Weeks start on Monday.
+func BucketStart(t time.Time, granularity string, loc *time.Location) time.Time {
+    t = t.In(loc)
+
+    switch granularity {
+    case "minute":
+        return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+    case "hour":
+        return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+    case "day":
+        return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+    default:
+        daysSinceMonday := (int(t.Weekday()) + 6) % 7
+        return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, loc)
+    }
+}
+
+// nextBucket returns the start of the bucket following start. Calendar
+// arithmetic keeps day and week buckets aligned across DST changes.
+func nextBucket(start time.Time, granularity string) time.Time {
+    switch granularity {
+    case "minute":
+        return start.Add(time.Minute)
+    case "hour":
+        return start.Add(time.Hour)
+    case "day":
+        return start.AddDate(0, 0, 1)
+    default:
+        return start.AddDate(0, 0, 7)
+    }
+}
+
+// HourAligned reports whether loc's UTC offset is a whole number of hours
+// at both ends of the range, i.e. whether UTC hourly rollups can be
+// regrouped into local buckets without splitting an hour
+func HourAligned(from, to time.Time, loc *time.Location) bool {
+    _, fromOffset := from.In(loc).Zone()
+    _, toOffset := to.In(loc).Zone()
+    return fromOffset%3600 == 0 && toOffset%3600 == 0
+}
+
+// TooManyBuckets reports whether covering [from, to) in loc would need
+// more than MaxTimeSeriesBuckets buckets, without building them
+func TooManyBuckets(from, to time.Time, granularity string, loc *time.Location) bool {
+    n := 0
+    for start := BucketStart(from, granularity, loc); start.Before(to); start = nextBucket(start, granularity) {
+        if n >= MaxTimeSeriesBuckets {
+            return true
+        }
+        n++
+    }
+    return false
+}
+
+// BuildTimeSeries sums counts into consecutive buckets covering [from, to)
+// in loc, emitting zero for buckets without clicks
+func BuildTimeSeries(counts []models.ClickBucket, from, to time.Time, granularity string, loc *time.Location) ([]models.ClickBucket, error) {
+    var buckets []models.ClickBucket
+    index := make(map[int64]int)
+
+    for start := BucketStart(from, granularity, loc); start.Before(to); start = nextBucket(start, granularity) {
+        if len(buckets) >= MaxTimeSeriesBuckets {
+            return nil, ErrTooManyBuckets
+        }
+        index[start.Unix()] = len(buckets)
+        buckets = append(buckets, models.ClickBucket{Start: start})
+    }
+
+    for _, count := range counts {
+        start := BucketStart(count.Start, granularity, loc)
+        if i, ok := index[start.Unix()]; ok {
+            buckets[i].Clicks += count.Clicks
+        }
+    }
+
+    return buckets, nil
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+    "testing"
+    "time"
+    _ "time/tzdata"
+    
+    "github.com/heydeepakch/url-shortner-golang/models"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+    t.Helper()
+    
+    loc, err := time.LoadLocation(name)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return loc
+}
+
+func TestBucketStart(t *testing.T) {
+    berlin := mustLoadLocation(t, "Europe/Berlin")
+    kolkata := mustLoadLocation(t, "Asia/Kolkata")
+    
+    // 2024-03-06 23:30 UTC is Thursday 00:30 in Berlin and 05:00 in Kolkata
+    at := time.Date(2024, 3, 6, 23, 30, 45, 0, time.UTC)
+    
+    tests := []struct {
+        granularity string
+        loc         *time.Location
+        want        time.Time
+    }{
+        {"minute", time.UTC, time.Date(2024, 3, 6, 23, 30, 0, 0, time.UTC)},
+        {"hour", time.UTC, time.Date(2024, 3, 6, 23, 0, 0, 0, time.UTC)},
+        {"day", time.UTC, time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)},
+        {"day", berlin, time.Date(2024, 3, 7, 0, 0, 0, 0, berlin)},
+        {"hour", kolkata, time.Date(2024, 3, 7, 5, 0, 0, 0, kolkata)},
+        {"week", time.UTC, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+        {"week", berlin, time.Date(2024, 3, 4, 0, 0, 0, 0, berlin)},
+    }
+    
+    for _, tt := range tests {
+        got := BucketStart(at, tt.granularity, tt.loc)
+        if !got.Equal(tt.want) {
+            t.Errorf("BucketStart(%s, %s) = %v, want %v", tt.granularity, tt.loc, got, tt.want)
+        }
+    }
+}
+
+func TestBucketStartWeekStartsOnMonday(t *testing.T) {
+    sunday := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+    want := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+    if got := BucketStart(sunday, "week", time.UTC); !got.Equal(want) {
+        t.Fatalf("BucketStart(Sunday) = %v, want %v", got, want)
+    }
+}
+
+func TestBuildTimeSeriesAcrossDST(t *testing.T) {
+    berlin := mustLoadLocation(t, "Europe/Berlin")
+    
+    // Clocks go forward at 02:00 on 2024-03-31, so that day has 23 hours
+    from := time.Date(2024, 3, 30, 0, 0, 0, 0, berlin)
+    to := time.Date(2024, 4, 2, 0, 0, 0, 0, berlin)
+    counts := []models.ClickBucket{
+        {Start: time.Date(2024, 3, 30, 22, 30, 0, 0, time.UTC), Clicks: 1}, // 03-30 23:30 CET
+        {Start: time.Date(2024, 3, 31, 21, 59, 0, 0, time.UTC), Clicks: 2}, // 03-31 23:59 CEST
+        {Start: time.Date(2024, 3, 31, 22, 0, 0, 0, time.UTC), Clicks: 4},  // 04-01 00:00 CEST
+    }
+    
+    buckets, err := BuildTimeSeries(counts, from, to, "day", berlin)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    wantStarts := []time.Time{
+        time.Date(2024, 3, 30, 0, 0, 0, 0, berlin),
+        time.Date(2024, 3, 31, 0, 0, 0, 0, berlin),
+        time.Date(2024, 4, 1, 0, 0, 0, 0, berlin),
+    }
+    wantClicks := []int64{1, 2, 4}
+    if len(buckets) != len(wantStarts) {
+        t.Fatalf("got %d buckets, want %d", len(buckets), len(wantStarts))
+    }
+    for i, bucket := range buckets {
+        if !bucket.Start.Equal(wantStarts[i]) || bucket.Clicks != wantClicks[i] {
+            t.Errorf("bucket %d = %v/%d, want %v/%d", i, bucket.Start, bucket.Clicks, wantStarts[i], wantClicks[i])
+        }
+    }
+}
+
+func TestBuildTimeSeriesHoursSkipMissingHour(t *testing.T) {
+    berlin := mustLoadLocation(t, "Europe/Berlin")
+    
+    // 02:00-03:00 does not exist on 2024-03-31 in Berlin
+    from := time.Date(2024, 3, 31, 0, 0, 0, 0, berlin)
+    to := time.Date(2024, 3, 31, 5, 0, 0, 0, berlin)
+    
+    buckets, err := BuildTimeSeries(nil, from, to, "hour", berlin)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    wantHours := []int{0, 1, 3, 4}
+    if len(buckets) != len(wantHours) {
+        t.Fatalf("got %d buckets, want %d", len(buckets), len(wantHours))
+    }
+    for i, bucket := range buckets {
+        if hour := bucket.Start.In(berlin).Hour(); hour != wantHours[i] {
+            t.Errorf("bucket %d starts at %02d:00, want %02d:00", i, hour, wantHours[i])
+        }
+    }
+}
+
+func TestBuildTimeSeriesTooManyBuckets(t *testing.T) {
+    to := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+    from := to.Add(-(MaxTimeSeriesBuckets + 1) * time.Minute)
+    
+    if !TooManyBuckets(from, to, "minute", time.UTC) {
+        t.Error("TooManyBuckets = false for one bucket over the limit")
+    }
+    if _, err := BuildTimeSeries(nil, from, to, "minute", time.UTC); err != ErrTooManyBuckets {
+        t.Errorf("BuildTimeSeries error = %v, want ErrTooManyBuckets", err)
+    }
+    
+    from = to.Add(-MaxTimeSeriesBuckets * time.Minute)
+    if TooManyBuckets(from, to, "minute", time.UTC) {
+        t.Error("TooManyBuckets = true at exactly the limit")
+    }
+}
+
+func TestHourAligned(t *testing.T) {
+    from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+    to := from.Add(24 * time.Hour)
+    
+    if !HourAligned(from, to, mustLoadLocation(t, "Europe/Berlin")) {
+        t.Error("Europe/Berlin should be hour aligned")
+    }
+    if HourAligned(from, to, mustLoadLocation(t, "Asia/Kolkata")) {
+        t.Error("Asia/Kolkata should not be hour aligned")
+    }
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+    "net/url"
+    "strings"
+)
+
+// UserAgentInfo is the coarse classification of a User-Agent string
+// used for click breakdowns
+type UserAgentInfo struct {
+    Browser string
+    OS      string
+    Device  string // desktop, mobile, tablet or bot
+}
+
+// uaRule maps a User-Agent substring to a name. Order matters: more
+// specific tokens must come before the ones they also contain (Edge and
+// Opera UAs also contain "Chrome", Chrome UAs also contain "Safari").
+type uaRule struct {
+    token string
+    name  string
+}
+
+var browserRules = []uaRule{
+    {"edg/", "Edge"},
+    {"edge/", "Edge"},
+    {"opr/", "Opera"},
+    {"opera", "Opera"},
+    {"samsungbrowser", "Samsung Internet"},
+    {"yabrowser", "Yandex"},
+    {"fban", "Facebook"},
+    {"fbav", "Facebook"},
+    {"instagram", "Instagram"},
+    {"firefox/", "Firefox"},
+    {"fxios", "Firefox"},
+    {"crios", "Chrome"},
+    {"chrome/", "Chrome"},
+    {"chromium", "Chromium"},
+    {"msie", "Internet Explorer"},
+    {"trident/", "Internet Explorer"},
+    {"safari/", "Safari"},
+    {"curl/", "curl"},
+    {"wget/", "Wget"},
+}
+
+var osRules = []uaRule{
+    {"windows phone", "Windows Phone"},
+    {"windows", "Windows"},
+    {"iphone", "iOS"},
+    {"ipad", "iOS"},
+    {"ipod", "iOS"},
+    {"android", "Android"},
+    {"cros", "ChromeOS"},
+    {"mac os x", "macOS"},
+    {"macintosh", "macOS"},
+    {"linux", "Linux"},
+}
+
+// ParseUserAgent classifies ua into browser family, OS and device class.
+// Unrecognised values are left empty.
+func ParseUserAgent(ua string) UserAgentInfo {
+    lower := strings.ToLower(ua)
+    info := UserAgentInfo{
+        Browser: matchRule(lower, browserRules),
+        OS:      matchRule(lower, osRules),
+    }
+
+    switch {
+    case lower == "":
+        info.Device = ""
+    case IsBotUserAgent(ua):
+        info.Device = "bot"
+    case strings.Contains(lower, "ipad") || strings.Contains(lower, "tablet") ||
+        (strings.Contains(lower, "android") && !strings.Contains(lower, "mobile")):
+        info.Device = "tablet"
+    case strings.Contains(lower, "mobi") || strings.Contains(lower, "iphone") || strings.Contains(lower, "ipod"):
+        info.Device = "mobile"
+    default:
+        info.Device = "desktop"
+    }
+
+    return info
+}
+
+// KnownOS reports whether name, ignoring case, is an OS family that
+// ParseUserAgent can return
+func KnownOS(name string) bool {
+    for _, rule := range osRules {
+        if strings.EqualFold(rule.name, name) {
+            return true
+        }
+    }
+    return false
+}
+
+func matchRule(lower string, rules []uaRule) string {
+    for _, rule := range rules {
+        if strings.Contains(lower, rule.token) {
+            return rule.name
+        }
+    }
+    return ""
+}
+
+func containsAny(s string, tokens []string) bool {
+    for _, token := range tokens {
+        if strings.Contains(s, token) {
+            return true
+        }
+    }
+    return false
+}
+
+// referrerAliases folds link wrappers and regional hosts into the site
+// people know them by
+var referrerAliases = map[string]string{
+    "t.co":                  "twitter.com",
+    "x.com":                 "twitter.com",
+    "l.facebook.com":        "facebook.com",
+    "lm.facebook.com":       "facebook.com",
+    "m.facebook.com":        "facebook.com",
+    "l.instagram.com":       "instagram.com",
+    "out.reddit.com":        "reddit.com",
+    "old.reddit.com":        "reddit.com",
+    "lnkd.in":               "linkedin.com",
+    "l.messenger.com":       "messenger.com",
+    "com.google.android.gm": "gmail",
+}
+
+// ReferrerDomain reduces a Referer header to its site, e.g.
+// "https://t.co/abc" becomes "twitter.com". Empty means a direct visit.
+func ReferrerDomain(referrer string) string {
+    if referrer == "" {
+        return ""
+    }
+
+    parsed, err := url.Parse(referrer)
+    if err != nil || parsed.Hostname() == "" {
+        return ""
+    }
+
+    host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+    if alias, ok := referrerAliases[host]; ok {
+        return alias
+    }
+    return host
+}